@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+
+	sigsci "github.com/signalsciences/go-sigsci"
+)
+
+// alertResourceTypes are the resources of custom alerts, by action: site
+// alerts on signals log an event or flag the IP, agent alerts watch a site
+// metric
+var alertResourceTypes = map[string]string{
+	"info":           "sigsci_site_alert",
+	"flagged":        "sigsci_site_alert",
+	"siteMetricInfo": "sigsci_site_agent_alert",
+}
+
+// alertClass is how a custom alert is represented in Terraform: as a
+// resource of its own, as part of the templated rule owning it or, with a
+// reason, not at all
+type alertClass struct {
+	ResourceType string
+	// Owner is the address of the templated rule holding the alert
+	Owner  string
+	Reason string
+}
+
+// classify_alert classifies an alert of site. Alerts of legacy templated
+// rules belong to the rule among templates with the alert or, for template
+// alerts, the name of the alert's signal.
+func classify_alert(site string, templates []ResponseSiteLegacyTemplatedRuleBody, alert sigsci.CustomAlert) alertClass {
+	for _, template := range templates {
+		owned := alert.Type == "template" && template.Name == alert.TagName
+		for _, templateAlert := range template.Alerts {
+			owned = owned || templateAlert.ID != "" && templateAlert.ID == alert.ID
+		}
+		if owned {
+			return alertClass{Owner: "sigsci_site_templated_rule." + site + sanitizeTfId(template.Name)}
+		}
+	}
+	if alert.Type == "template" {
+		return alertClass{Reason: fmt.Sprintf("belongs to templated rule %s, which is not rendered", alert.TagName)}
+	}
+	if resourceType, ok := alertResourceTypes[alert.Action]; ok {
+		return alertClass{ResourceType: resourceType}
+	}
+	return alertClass{Reason: fmt.Sprintf("action %q has no Terraform representation", alert.Action)}
+}
+
+// unrepresented_alerts describes the alerts of snapshot that no resource
+// holds
+func unrepresented_alerts(snapshot CorpSnapshot) []string {
+	var report []string
+	for _, site := range snapshot.Sites {
+		for _, alert := range site.Alerts {
+			class := classify_alert(site.Site.Name, site.LegacyTemplatedRules.Data, alert)
+			if class.Reason != "" {
+				report = append(report, fmt.Sprintf("site %s, alert %s (%s): %s, not imported", site.Site.Name, alert.ID, alert.LongName, class.Reason))
+			}
+		}
+	}
+	return report
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	sigsci "github.com/signalsciences/go-sigsci"
+)
+
+func TestClassifyAlert(t *testing.T) {
+	templates := []ResponseSiteLegacyTemplatedRuleBody{{
+		Name:   "LOGINATTEMPT",
+		Alerts: []sigsci.CustomAlert{{ID: "65f60718293a4b5c6d7e8f91"}},
+	}}
+	for _, c := range []struct {
+		name  string
+		alert sigsci.CustomAlert
+		want  alertClass
+	}{
+		{"info", sigsci.CustomAlert{Action: "info", TagName: "SQLI"}, alertClass{ResourceType: "sigsci_site_alert"}},
+		{"flagged", sigsci.CustomAlert{Action: "flagged", TagName: "SQLI"}, alertClass{ResourceType: "sigsci_site_alert"}},
+		{"agent", sigsci.CustomAlert{Action: "siteMetricInfo", TagName: "requests_total"}, alertClass{ResourceType: "sigsci_site_agent_alert"}},
+		{"template by ID", sigsci.CustomAlert{ID: "65f60718293a4b5c6d7e8f91", Action: "info", TagName: "LOGINATTEMPT"}, alertClass{Owner: "sigsci_site_templated_rule.wwwLOGINATTEMPT"}},
+		{"template by signal", sigsci.CustomAlert{Type: "template", Action: "info", TagName: "LOGINATTEMPT"}, alertClass{Owner: "sigsci_site_templated_rule.wwwLOGINATTEMPT"}},
+		{"template not rendered", sigsci.CustomAlert{Type: "template", Action: "info", TagName: "CCARD"}, alertClass{Reason: "belongs to templated rule CCARD, which is not rendered"}},
+		{"unknown action", sigsci.CustomAlert{Action: "siteMetricFlagged", TagName: "requests_total"}, alertClass{Reason: `action "siteMetricFlagged" has no Terraform representation`}},
+	} {
+		if got := classify_alert("www", templates, c.alert); got != c.want {
+			t.Errorf("%s: got %+v, want %+v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestUnrepresentedAlerts(t *testing.T) {
+	snapshot := CorpSnapshot{Sites: []SiteSnapshot{{
+		Site: sigsci.Site{Name: "www"},
+		Alerts: []sigsci.CustomAlert{
+			{ID: "64e5f60718293a4b5c6d7e8f", LongName: "Too many logins", Action: "info"},
+			{ID: "64e5f60718293a4b5c6d7e92", LongName: "Metric flag", Action: "siteMetricFlagged"},
+		},
+	}}}
+	want := []string{`site www, alert 64e5f60718293a4b5c6d7e92 (Metric flag): action "siteMetricFlagged" has no Terraform representation, not imported`}
+	if got := unrepresented_alerts(snapshot); !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
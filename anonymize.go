@@ -0,0 +1,278 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+
+	sigsci "github.com/signalsciences/go-sigsci"
+)
+
+// anonymizer replaces IP addresses, hostnames, URLs and email addresses
+// with pseudonyms of the same shape. A value gets the same pseudonym
+// wherever it appears, so references between objects survive, and with
+// the same key the same pseudonym in every run.
+type anonymizer struct {
+	key []byte
+	// pseudonyms and originals map values to their pseudonyms and back,
+	// to keep two values from sharing a pseudonym
+	pseudonyms map[string]string
+	originals  map[string]string
+}
+
+// new_anonymizer returns an anonymizer keyed with key, or a random key
+// when empty
+func new_anonymizer(key string) *anonymizer {
+	a := &anonymizer{key: []byte(key), pseudonyms: map[string]string{}, originals: map[string]string{}}
+	if key == "" {
+		a.key = make([]byte, 32)
+		if _, err := rand.Read(a.key); err != nil {
+			panic(err)
+		}
+	}
+	return a
+}
+
+var (
+	emailPattern    = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+	hostnamePattern = regexp.MustCompile(`^(\*\.)?([a-zA-Z0-9_-]+\.)+[a-zA-Z][a-zA-Z0-9-]*\.?$`)
+)
+
+// pseudonym returns the pseudonym of value of the given kind, generating
+// one from the keyed hash with format the first time
+func (a *anonymizer) pseudonym(kind string, value string, format func(sum []byte) string) string {
+	if value == "" {
+		return ""
+	}
+	if pseudonym, ok := a.pseudonyms[kind+":"+value]; ok {
+		return pseudonym
+	}
+	for attempt := 0; ; attempt++ {
+		mac := hmac.New(sha256.New, a.key)
+		fmt.Fprintf(mac, "%s:%d:%s", kind, attempt, value)
+		pseudonym := format(mac.Sum(nil))
+		if original, taken := a.originals[kind+":"+pseudonym]; taken && original != value {
+			continue
+		}
+		a.pseudonyms[kind+":"+value] = pseudonym
+		a.originals[kind+":"+pseudonym] = value
+		return pseudonym
+	}
+}
+
+// ip pseudonymizes an address or CIDR into 10.0.0.0/8 or fd00::/8, keeping
+// the prefix length. Prefixes shorter than the pseudonym range identify
+// nobody and are kept.
+func (a *anonymizer) ip(value string) string {
+	address, prefix, isCIDR := strings.Cut(value, "/")
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return value
+	}
+	bits := 128
+	base := net.ParseIP("fd00::")
+	if ip.To4() != nil {
+		bits = 32
+		base = net.ParseIP("10.0.0.0").To4()
+	}
+	ones := bits
+	if isCIDR {
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			return value
+		}
+		if ones, _ = network.Mask.Size(); ones < 8 {
+			return value
+		}
+		address = network.IP.String()
+	}
+	return a.pseudonym("ip", address+"/"+prefix, func(sum []byte) string {
+		pseudonym := make(net.IP, len(base))
+		copy(pseudonym, base)
+		copy(pseudonym[1:], sum)
+		pseudonym = pseudonym.Mask(net.CIDRMask(ones, bits))
+		if isCIDR {
+			return fmt.Sprintf("%s/%d", pseudonym, ones)
+		}
+		return pseudonym.String()
+	})
+}
+
+// hostname pseudonymizes a hostname, keeping a leading wildcard label
+func (a *anonymizer) hostname(value string) string {
+	if rest, ok := strings.CutPrefix(value, "*."); ok {
+		return "*." + a.hostname(rest)
+	}
+	return a.pseudonym("host", strings.ToLower(strings.TrimSuffix(value, ".")), func(sum []byte) string {
+		return "host-" + hex.EncodeToString(sum[:4]) + ".example.com"
+	})
+}
+
+// email pseudonymizes an email address
+func (a *anonymizer) email(value string) string {
+	return a.pseudonym("email", strings.ToLower(value), func(sum []byte) string {
+		return "user-" + hex.EncodeToString(sum[:4]) + "@example.com"
+	})
+}
+
+// url pseudonymizes the host and every path segment of a URL, which for
+// webhooks hold the credentials, and drops the query and fragment.
+// Placeholders of header links are kept.
+func (a *anonymizer) url(value string) string {
+	parsed, err := url.Parse(value)
+	if err != nil || parsed.Host == "" {
+		return value
+	}
+	host := parsed.Hostname()
+	if net.ParseIP(host) != nil {
+		host = a.ip(host)
+	} else {
+		host = a.hostname(host)
+	}
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if port := parsed.Port(); port != "" {
+		host += ":" + port
+	}
+	segments := strings.Split(parsed.EscapedPath(), "/")
+	for i, segment := range segments {
+		// Header links substitute request values into {{value}}
+		if segment != "" && !strings.Contains(segment, "%7B%7B") {
+			segments[i] = a.pseudonym("path", segment, func(sum []byte) string {
+				return hex.EncodeToString(sum[:4])
+			})
+		}
+	}
+	return parsed.Scheme + "://" + host + strings.ReplaceAll(strings.ReplaceAll(strings.Join(segments, "/"), "%7B", "{"), "%7D", "}")
+}
+
+// value pseudonymizes value if it is an IP address or CIDR, email address,
+// URL or hostname, and returns anything else unchanged
+func (a *anonymizer) value(value string) string {
+	trimmed := strings.TrimSpace(value)
+	address, _, _ := strings.Cut(trimmed, "/")
+	switch {
+	case trimmed == "":
+		return value
+	case net.ParseIP(address) != nil:
+		return a.ip(trimmed)
+	case emailPattern.MatchString(trimmed):
+		return a.email(trimmed)
+	case strings.Contains(trimmed, "://"):
+		return a.url(trimmed)
+	case hostnamePattern.MatchString(trimmed):
+		return a.hostname(trimmed)
+	}
+	return value
+}
+
+// anonymousListTypes are the list types whose entries are pseudonymized.
+// Country and signal lists hold nothing identifying.
+var anonymousListTypes = map[string]bool{"ip": true, "string": true, "wildcard": true}
+
+// anonymousConditionOperators are the operators whose condition values
+// are not pseudonymized: list references and existence checks
+var anonymousConditionOperators = map[string]bool{"inList": true, "notInList": true, "exists": true, "doesNotExist": true}
+
+// anonymize_snapshot pseudonymizes the identifying values of snapshot in
+// place: list entries, rule condition values and redirect URLs,
+// integration and header link URLs, redirect URLs of sites, templated rule
+// field values and every CreatedBy. IDs, names and list references are
+// kept, so the configuration rendered from it keeps its structure.
+func anonymize_snapshot(snapshot *CorpSnapshot, a *anonymizer) {
+	for i := range snapshot.Rules.Data {
+		rule := &snapshot.Rules.Data[i]
+		rule.CreatedBy = a.email(rule.CreatedBy)
+		anonymize_conditions(rule.Conditions, a)
+		anonymize_actions(rule.Actions, a)
+	}
+	for i := range snapshot.Lists.Data {
+		anonymize_list(&snapshot.Lists.Data[i], a)
+	}
+	for i := range snapshot.Signals.Data {
+		snapshot.Signals.Data[i].CreatedBy = a.email(snapshot.Signals.Data[i].CreatedBy)
+	}
+
+	for s := range snapshot.Sites {
+		site := &snapshot.Sites[s]
+		site.Site.BlockRedirectURL = a.value(site.Site.BlockRedirectURL)
+		for i := range site.Rules.Data {
+			rule := &site.Rules.Data[i]
+			rule.CreatedBy = a.email(rule.CreatedBy)
+			anonymize_conditions(rule.Conditions, a)
+			anonymize_actions(rule.Actions, a)
+		}
+		for i := range site.LegacyTemplatedRules.Data {
+			rule := &site.LegacyTemplatedRules.Data[i]
+			rule.CreatedBy = a.email(rule.CreatedBy)
+			anonymize_fields(rule.Fields, a)
+			for d := range rule.Detections {
+				rule.Detections[d].CreatedBy = a.email(rule.Detections[d].CreatedBy)
+				anonymize_fields(rule.Detections[d].Fields, a)
+			}
+			for d := range rule.Alerts {
+				rule.Alerts[d].CreatedBy = a.email(rule.Alerts[d].CreatedBy)
+			}
+		}
+		for i := range site.Signals.Data {
+			site.Signals.Data[i].CreatedBy = a.email(site.Signals.Data[i].CreatedBy)
+		}
+		for i := range site.Lists.Data {
+			anonymize_list(&site.Lists.Data[i], a)
+		}
+		for i := range site.Integrations {
+			site.Integrations[i].URL = a.url(site.Integrations[i].URL)
+			site.Integrations[i].CreatedBy = a.email(site.Integrations[i].CreatedBy)
+		}
+		for i := range site.HeaderLinks {
+			site.HeaderLinks[i].Link = a.url(site.HeaderLinks[i].Link)
+			site.HeaderLinks[i].CreatedBy = a.email(site.HeaderLinks[i].CreatedBy)
+		}
+		for i := range site.Alerts {
+			site.Alerts[i].CreatedBy = a.email(site.Alerts[i].CreatedBy)
+		}
+	}
+}
+
+func anonymize_list(list *sigsci.ResponseListBody, a *anonymizer) {
+	list.CreatedBy = a.email(list.CreatedBy)
+	if !anonymousListTypes[list.Type] {
+		return
+	}
+	for i, entry := range list.Entries {
+		list.Entries[i] = a.value(entry)
+	}
+}
+
+func anonymize_conditions(conditions []sigsci.Condition, a *anonymizer) {
+	for i := range conditions {
+		condition := &conditions[i]
+		anonymize_conditions(condition.Conditions, a)
+		switch {
+		case condition.Type != "single" || anonymousConditionOperators[condition.Operator]:
+		case condition.Field == "domain":
+			condition.Value = a.hostname(condition.Value)
+		default:
+			condition.Value = a.value(condition.Value)
+		}
+	}
+}
+
+func anonymize_actions(actions []sigsci.Action, a *anonymizer) {
+	for i := range actions {
+		actions[i].RedirectURL = a.value(actions[i].RedirectURL)
+	}
+}
+
+func anonymize_fields(fields []Field, a *anonymizer) {
+	for i := range fields {
+		fields[i].Value = a.value(fields[i].Value)
+	}
+}
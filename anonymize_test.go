@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestAnonymizeValues(t *testing.T) {
+	a := new_anonymizer("test-key")
+	shapes := map[string]string{
+		"203.0.113.7":                           "^10\\.\\d+\\.\\d+\\.\\d+$",
+		"198.51.100.0/24":                       "^10\\.\\d+\\.\\d+\\.0/24$",
+		"2001:db8::1":                           "^fd[0-9a-f]{2}:",
+		"0.0.0.0/0":                             "^0\\.0\\.0\\.0/0$",
+		"alice@example.org":                     "^user-[0-9a-f]{8}@example\\.com$",
+		"api.acme.io":                           "^host-[0-9a-f]{8}\\.example\\.com$",
+		"*.acme.io":                             "^\\*\\.host-[0-9a-f]{8}\\.example\\.com$",
+		"https://hooks.slack.com/T0":            "^https://host-[0-9a-f]{8}\\.example\\.com/[0-9a-f]{8}$",
+		"https://t.acme.io/trace/{{value}}?q=1": "^https://host-[0-9a-f]{8}\\.example\\.com/[0-9a-f]{8}/\\{\\{value\\}\\}$",
+		"/admin":                                "^/admin$",
+		"POST":                                  "^POST$",
+	}
+	for value, shape := range shapes {
+		if got := a.value(value); !regexp.MustCompile(shape).MatchString(got) {
+			t.Errorf("%s pseudonymized to %s, want %s", value, got, shape)
+		}
+	}
+
+	if a.value("203.0.113.7") != new_anonymizer("test-key").value("203.0.113.7") {
+		t.Error("the same key gives different pseudonyms")
+	}
+	if a.value("203.0.113.7") == new_anonymizer("other-key").value("203.0.113.7") {
+		t.Error("different keys give the same pseudonym")
+	}
+	if a.value("API.acme.io") != a.value("api.acme.io") {
+		t.Error("hostnames are case insensitive")
+	}
+}
+
+func TestAnonymizeSnapshot(t *testing.T) {
+	_, sc, api := newFakeAPI(t, "testcorp")
+	snapshot, err := fetch_corp_snapshot(context.Background(), sc, api, "testcorp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := new_anonymizer("test-key")
+	anonymize_snapshot(&snapshot, a)
+
+	content, err := json.Marshal(snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, leak := range []string{"203.0.113.7", "198.51.100.0", "192.0.2.10", "alice@", "bob@", "carol@", "hooks.slack.com", "XXXXSECRET", "tracing.example.com"} {
+		if strings.Contains(string(content), leak) {
+			t.Errorf("anonymized snapshot still holds %s", leak)
+		}
+	}
+
+	// List references and the structure of rules are kept
+	for _, keep := range []string{"corp.blocked-ips", "site.office-ips", "/admin", "{{value}}"} {
+		if !strings.Contains(string(content), keep) {
+			t.Errorf("anonymized snapshot lost %s", keep)
+		}
+	}
+	if snapshot.Rules.Data[0].CreatedBy != a.email("alice@example.com") {
+		t.Errorf("CreatedBy is %s", snapshot.Rules.Data[0].CreatedBy)
+	}
+	if got, want := snapshot.Sites[0].Lists.Data[0].Entries, []string{a.ip("192.0.2.10"), a.ip("192.0.2.11")}; strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("site list entries are %v, want %v", got, want)
+	}
+}
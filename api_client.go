@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultAPIURL = "https://dashboard.signalsciences.net/api"
+
+// APIClient is the shared client for raw NGWAF API endpoints that go-sigsci
+// does not cover (e.g. the legacy configured templates).
+type APIClient struct {
+	BaseURL    string
+	Email      string
+	Token      string
+	HTTPClient *http.Client
+	// Timeout bounds each individual attempt, not the whole retry sequence
+	Timeout    time.Duration
+	MaxRetries int
+	RetryWait  time.Duration
+	// MaxRetryAfter is the longest Retry-After the client waits for; a
+	// response asking for longer fails without a retry
+	MaxRetryAfter time.Duration
+}
+
+// NewAPIClient returns an APIClient with sensible defaults for the NGWAF API
+func NewAPIClient(email string, token string) *APIClient {
+	return &APIClient{
+		BaseURL:       defaultAPIURL,
+		Email:         email,
+		Token:         token,
+		HTTPClient:    &http.Client{},
+		Timeout:       30 * time.Second,
+		MaxRetries:    3,
+		RetryWait:     time.Second,
+		MaxRetryAfter: 5 * time.Minute,
+	}
+}
+
+// APIError describes a failed call to the NGWAF API
+type APIError struct {
+	Method     string
+	Endpoint   string
+	StatusCode int
+	Message    string
+	Err        error
+}
+
+func (e *APIError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s %s: %v", e.Method, e.Endpoint, e.Err)
+	}
+	if e.Message != "" {
+		return fmt.Sprintf("%s %s: status %d: %s", e.Method, e.Endpoint, e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("%s %s: status %d", e.Method, e.Endpoint, e.StatusCode)
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// Do sends the request and returns the response body. Transport errors, 429
+// and 5xx responses are retried with exponential backoff until MaxRetries is
+// exhausted or ctx is cancelled. A Retry-After longer than MaxRetryAfter is
+// not waited for. POST and PATCH requests may have been applied when they
+// time out or fail with a 5xx, so they are only retried after a 429.
+func (c *APIClient) Do(ctx context.Context, method string, endpoint string, reqBody string) ([]byte, error) {
+	wait := c.RetryWait
+	for attempt := 0; ; attempt++ {
+		body, retryAfter, err := c.doOnce(ctx, method, endpoint, reqBody)
+		if err == nil {
+			return body, nil
+		}
+		if attempt >= c.MaxRetries || !isRetryable(method, err) || ctx.Err() != nil {
+			return nil, err
+		}
+
+		delay := wait
+		if retryAfter > 0 {
+			if c.MaxRetryAfter > 0 && retryAfter > c.MaxRetryAfter {
+				return nil, err
+			}
+			delay = retryAfter
+		}
+		select {
+		case <-ctx.Done():
+			return nil, &APIError{Method: method, Endpoint: endpoint, Err: ctx.Err()}
+		case <-time.After(delay):
+		}
+		wait *= 2
+	}
+}
+
+func (c *APIClient) doOnce(ctx context.Context, method string, endpoint string, reqBody string) ([]byte, time.Duration, error) {
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+
+	var b io.Reader
+	if reqBody != "" {
+		b = strings.NewReader(reqBody)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+endpoint, b)
+	if err != nil {
+		return nil, 0, &APIError{Method: method, Endpoint: endpoint, Err: err}
+	}
+
+	if c.Email != "" {
+		// token auth
+		req.Header.Set("X-API-User", c.Email)
+		req.Header.Set("X-API-Token", c.Token)
+	}
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "go-sigsci")
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, 0, &APIError{Method: method, Endpoint: endpoint, Err: err}
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, &APIError{Method: method, Endpoint: endpoint, StatusCode: resp.StatusCode, Err: err}
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, parseRetryAfter(resp.Header.Get("Retry-After")), &APIError{
+			Method:     method,
+			Endpoint:   endpoint,
+			StatusCode: resp.StatusCode,
+			Message:    strings.TrimSpace(string(body)),
+		}
+	}
+	return body, 0, nil
+}
+
+// idempotentMethods can be repeated without changing the result
+var idempotentMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodOptions: true,
+	http.MethodPut:     true,
+	http.MethodDelete:  true,
+}
+
+func isRetryable(method string, err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	if apiErr.StatusCode == http.StatusTooManyRequests {
+		// Rejected before it was handled
+		return true
+	}
+	if !idempotentMethods[method] {
+		return false
+	}
+	if apiErr.Err != nil {
+		// Transport failures are retried, cancellation is not
+		return !errors.Is(apiErr.Err, context.Canceled)
+	}
+	return apiErr.StatusCode >= 500
+}
+
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if when, err := http.ParseTime(value); err == nil {
+		return time.Until(when)
+	}
+	return 0
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAPIClientRetriesServerErrors(t *testing.T) {
+	server, _, api := newFakeAPI(t, "testcorp")
+	server.FailNext("corps/testcorp/sites/www/configuredtemplates", http.StatusBadGateway, http.StatusTooManyRequests)
+
+	rules, err := get_active_legacy_templated_rules(context.Background(), api, "testcorp", "www")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules.Data) != 2 {
+		t.Errorf("got %d templated rules, want 2", len(rules.Data))
+	}
+	if got := len(server.Requests()); got != 3 {
+		t.Errorf("got %d requests, want 3", got)
+	}
+}
+
+func TestAPIClientGivesUpAfterMaxRetries(t *testing.T) {
+	server, _, api := newFakeAPI(t, "testcorp")
+	api.MaxRetries = 1
+	server.FailNext("corps/testcorp/sites/www/configuredtemplates", http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+
+	_, err := get_active_legacy_templated_rules(context.Background(), api, "testcorp", "www")
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("got %v, want an APIError", err)
+	}
+	if apiErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("got status %d, want %d", apiErr.StatusCode, http.StatusServiceUnavailable)
+	}
+	if apiErr.Endpoint != "/v0/corps/testcorp/sites/www/configuredtemplates" {
+		t.Errorf("got endpoint %q", apiErr.Endpoint)
+	}
+}
+
+func TestAPIClientDoesNotRetryNonIdempotentRequests(t *testing.T) {
+	server, _, api := newFakeAPI(t, "testcorp")
+	server.FailNext("corps/testcorp/sites/www/lists", http.StatusBadGateway)
+
+	_, err := api.Do(context.Background(), "POST", "/v0/corps/testcorp/sites/www/lists", `{"name": "new-ips", "type": "ip", "entries": []}`)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadGateway {
+		t.Fatalf("got %v, want a %d APIError", err, http.StatusBadGateway)
+	}
+	if got := len(server.Requests()); got != 1 {
+		t.Errorf("got %d requests, want 1", got)
+	}
+
+	server.FailNext("corps/testcorp/sites/www/lists", http.StatusTooManyRequests)
+	if _, err := api.Do(context.Background(), "POST", "/v0/corps/testcorp/sites/www/lists", `{"name": "new-ips", "type": "ip", "entries": []}`); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(server.Requests()); got != 3 {
+		t.Errorf("got %d requests, want 3", got)
+	}
+}
+
+func TestAPIClientDoesNotRetryClientErrors(t *testing.T) {
+	server, _, api := newFakeAPI(t, "testcorp")
+	api.Email = ""
+
+	if _, err := api.Do(context.Background(), "GET", "/v0/corps/testcorp/sites", ""); err == nil {
+		t.Fatal("expected an error without credentials")
+	}
+	if got := len(server.Requests()); got != 1 {
+		t.Errorf("got %d requests, want 1", got)
+	}
+}
+
+func TestAPIClientCancelled(t *testing.T) {
+	_, _, api := newFakeAPI(t, "testcorp")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := api.Do(ctx, "GET", "/v0/corps/testcorp/sites", "")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want context.Canceled", err)
+	}
+}
+
+func TestAPIClientLongRetryAfter(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Retry-After", "86400")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	t.Cleanup(server.Close)
+	api := NewAPIClient("test@example.com", "test-token")
+	api.BaseURL = server.URL
+
+	_, err := api.Do(context.Background(), "GET", "/v0/corps", "")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("got %v, want a 429 APIError", err)
+	}
+	if requests != 1 {
+		t.Errorf("got %d requests, want 1", requests)
+	}
+}
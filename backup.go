@@ -0,0 +1,345 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"time"
+
+	sigsci "github.com/signalsciences/go-sigsci"
+)
+
+// backupFormatVersion is the version of the backup archive layout. Restore
+// refuses archives of a newer version.
+const backupFormatVersion = 1
+
+// backupManifest describes a backup archive
+type backupManifest struct {
+	FormatVersion int       `json:"formatVersion"`
+	Corp          string    `json:"corp"`
+	Created       time.Time `json:"created"`
+	// Anonymized archives hold pseudonyms, not the corp's data, and are
+	// never restored
+	Anonymized bool `json:"anonymized,omitempty"`
+}
+
+// Files of a backup archive
+const (
+	backupManifestFile = "manifest.json"
+	backupSnapshotFile = "snapshot.json"
+)
+
+// write_backup writes snapshot to a gzipped tar archive at path, with a
+// manifest recording the format version and whether snapshot is anonymized
+func write_backup(path string, snapshot CorpSnapshot, created time.Time, anonymized bool) error {
+	manifest, err := json.MarshalIndent(backupManifest{FormatVersion: backupFormatVersion, Corp: snapshot.Corp, Created: created.UTC(), Anonymized: anonymized}, "", "  ")
+	if err != nil {
+		return err
+	}
+	content, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("error creating %s: %v", path, err)
+	}
+	defer file.Close()
+	gz := gzip.NewWriter(file)
+	archive := tar.NewWriter(gz)
+	for _, entry := range []struct {
+		name    string
+		content []byte
+	}{{backupManifestFile, manifest}, {backupSnapshotFile, content}} {
+		header := &tar.Header{Name: entry.name, Mode: 0644, Size: int64(len(entry.content)), ModTime: created}
+		if err := archive.WriteHeader(header); err != nil {
+			return fmt.Errorf("error writing %s: %v", path, err)
+		}
+		if _, err := archive.Write(entry.content); err != nil {
+			return fmt.Errorf("error writing %s: %v", path, err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("error writing %s: %v", path, err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("error writing %s: %v", path, err)
+	}
+	return file.Close()
+}
+
+// read_backup reads a backup archive written by write_backup
+func read_backup(path string) (backupManifest, CorpSnapshot, error) {
+	var manifest backupManifest
+	var snapshot CorpSnapshot
+
+	file, err := os.Open(path)
+	if err != nil {
+		return manifest, snapshot, fmt.Errorf("error opening %s: %v", path, err)
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return manifest, snapshot, fmt.Errorf("error reading %s: %v", path, err)
+	}
+	archive := tar.NewReader(gz)
+
+	found := map[string]bool{}
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return manifest, snapshot, fmt.Errorf("error reading %s: %v", path, err)
+		}
+		var target interface{}
+		switch header.Name {
+		case backupManifestFile:
+			target = &manifest
+		case backupSnapshotFile:
+			target = &snapshot
+		default:
+			continue
+		}
+		if err := json.NewDecoder(archive).Decode(target); err != nil {
+			return manifest, snapshot, fmt.Errorf("error reading %s from %s: %v", header.Name, path, err)
+		}
+		found[header.Name] = true
+	}
+
+	if !found[backupManifestFile] || !found[backupSnapshotFile] {
+		return manifest, snapshot, fmt.Errorf("%s is not a backup archive", path)
+	}
+	if manifest.FormatVersion > backupFormatVersion {
+		return manifest, snapshot, fmt.Errorf("%s has format version %d, this version reads up to %d", path, manifest.FormatVersion, backupFormatVersion)
+	}
+	return manifest, snapshot, nil
+}
+
+// restore_plan returns the requests recreating the objects of backup that
+// corp, currently holding current, lacks. Objects are matched by ID, and,
+// as recreated objects get new IDs, lists and signals by name and
+// everything else by content. Requests come in dependency order: corp
+// signals and lists, sites, site signals and lists, rules, alerts,
+// integrations and header links. Templated rules are not restored.
+func restore_plan(corp string, backup CorpSnapshot, current CorpSnapshot) []apiRequest {
+	var plan []apiRequest
+	add := func(description string, path string, body interface{}) {
+		plan = append(plan, apiRequest{Address: description, Method: "POST", Path: path, Body: body})
+	}
+	corpPath := "/v0/corps/" + corp
+
+	for _, signal := range backup.Signals.Data {
+		if !hasSignal(current.Signals.Data, signal) {
+			add("corp signal "+signal.TagName, corpPath+"/tags", signal.CreateSignalTagBody)
+		}
+	}
+	for _, list := range backup.Lists.Data {
+		if !hasList(current.Lists.Data, list) {
+			add("corp list "+list.ID, corpPath+"/lists", list.CreateListBody)
+		}
+	}
+
+	currentSites := map[string]SiteSnapshot{}
+	for _, site := range current.Sites {
+		currentSites[site.Site.Name] = site
+	}
+	for _, site := range backup.Sites {
+		if _, ok := currentSites[site.Site.Name]; !ok {
+			add("site "+site.Site.Name, corpPath+"/sites", sigsci.CreateSiteBody{
+				Name:                 site.Site.Name,
+				DisplayName:          site.Site.DisplayName,
+				AgentLevel:           site.Site.AgentLevel,
+				AgentAnonMode:        site.Site.AgentAnonMode,
+				BlockHTTPCode:        site.Site.BlockHTTPCode,
+				BlockRedirectURL:     site.Site.BlockRedirectURL,
+				BlockDurationSeconds: site.Site.BlockDurationSeconds,
+				ClientIPRules:        site.Site.ClientIPRules,
+				AttackThresholds:     site.Site.AttackThresholds,
+				ImmediateBlock:       site.Site.ImmediateBlock,
+			})
+		}
+	}
+	for _, site := range backup.Sites {
+		existing := currentSites[site.Site.Name]
+		sitePath := corpPath + "/sites/" + site.Site.Name
+		for _, signal := range site.Signals.Data {
+			if !hasSignal(existing.Signals.Data, signal) {
+				add("site signal "+site.Site.Name+"/"+signal.TagName, sitePath+"/tags", signal.CreateSignalTagBody)
+			}
+		}
+		for _, list := range site.Lists.Data {
+			if !hasList(existing.Lists.Data, list) {
+				add("site list "+site.Site.Name+"/"+list.ID, sitePath+"/lists", list.CreateListBody)
+			}
+		}
+	}
+
+	for _, rule := range backup.Rules.Data {
+		if !hasObject(current.Rules.Data, rule.ID, rule.CreateCorpRuleBody, func(existing sigsci.ResponseCorpRuleBody) (string, interface{}) {
+			return existing.ID, existing.CreateCorpRuleBody
+		}) {
+			add(fmt.Sprintf("corp rule %s (%s)", rule.ID, rule.Reason), corpPath+"/rules", rule.CreateCorpRuleBody)
+		}
+	}
+	for _, site := range backup.Sites {
+		existing := currentSites[site.Site.Name]
+		sitePath := corpPath + "/sites/" + site.Site.Name
+		for _, rule := range site.Rules.Data {
+			if !hasObject(existing.Rules.Data, rule.ID, rule.CreateSiteRuleBody, func(existing sigsci.ResponseSiteRuleBody) (string, interface{}) {
+				return existing.ID, existing.CreateSiteRuleBody
+			}) {
+				add(fmt.Sprintf("site rule %s/%s (%s)", site.Site.Name, rule.ID, rule.Reason), sitePath+"/rules", rule.CreateSiteRuleBody)
+			}
+		}
+	}
+
+	for _, site := range backup.Sites {
+		existing := currentSites[site.Site.Name]
+		sitePath := corpPath + "/sites/" + site.Site.Name
+		for _, alert := range site.Alerts {
+			if !hasObject(existing.Alerts, alert.ID, alertBody(alert), func(existing sigsci.CustomAlert) (string, interface{}) {
+				return existing.ID, alertBody(existing)
+			}) {
+				add(fmt.Sprintf("alert %s/%s (%s)", site.Site.Name, alert.ID, alert.LongName), sitePath+"/alerts", alertBody(alert))
+			}
+		}
+	}
+	for _, site := range backup.Sites {
+		existing := currentSites[site.Site.Name]
+		sitePath := corpPath + "/sites/" + site.Site.Name
+		for _, integration := range site.Integrations {
+			body := sigsci.IntegrationBody{URL: integration.URL, Type: integration.Type, Events: integration.Events}
+			if !hasObject(existing.Integrations, integration.ID, body, func(existing sigsci.Integration) (string, interface{}) {
+				return existing.ID, sigsci.IntegrationBody{URL: existing.URL, Type: existing.Type, Events: existing.Events}
+			}) {
+				add(fmt.Sprintf("integration %s/%s (%s)", site.Site.Name, integration.ID, integration.Type), sitePath+"/integrations", body)
+			}
+		}
+		for _, link := range site.HeaderLinks {
+			body := sigsci.HeaderLinkBody{Type: link.Type, Name: link.Name, LinkName: link.LinkName, Link: link.Link}
+			if !hasObject(existing.HeaderLinks, link.ID, body, func(existing sigsci.HeaderLink) (string, interface{}) {
+				return existing.ID, sigsci.HeaderLinkBody{Type: existing.Type, Name: existing.Name, LinkName: existing.LinkName, Link: existing.Link}
+			}) {
+				add(fmt.Sprintf("header link %s/%s (%s)", site.Site.Name, link.ID, link.Name), sitePath+"/headerLinks", body)
+			}
+		}
+	}
+	return plan
+}
+
+func alertBody(alert sigsci.CustomAlert) sigsci.CustomAlertBody {
+	return sigsci.CustomAlertBody{
+		TagName:              alert.TagName,
+		LongName:             alert.LongName,
+		Interval:             alert.Interval,
+		Threshold:            alert.Threshold,
+		Enabled:              alert.Enabled,
+		Action:               alert.Action,
+		SkipNotifications:    alert.SkipNotifications,
+		BlockDurationSeconds: alert.BlockDurationSeconds,
+	}
+}
+
+func hasSignal(signals []sigsci.ResponseSignalTagBody, signal sigsci.ResponseSignalTagBody) bool {
+	for _, existing := range signals {
+		if existing.TagName == signal.TagName || existing.ShortName == signal.ShortName {
+			return true
+		}
+	}
+	return false
+}
+
+func hasList(lists []sigsci.ResponseListBody, list sigsci.ResponseListBody) bool {
+	for _, existing := range lists {
+		if existing.ID == list.ID || existing.Name == list.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// hasObject reports whether objects holds an object with the given ID or
+// with a body equal to body, using key to get both from an object
+func hasObject[T any](objects []T, id string, body interface{}, key func(T) (string, interface{})) bool {
+	for _, object := range objects {
+		existingID, existingBody := key(object)
+		if existingID == id || reflect.DeepEqual(existingBody, body) {
+			return true
+		}
+	}
+	return false
+}
+
+func run_backup(ctx context.Context, sc sigsci.Client, api *APIClient, args []string) error {
+	flags := flag.NewFlagSet("backup", flag.ExitOnError)
+	corp := flags.String("corp", os.Getenv("TF_VAR_NGWAF_CORP"), "corp to back up")
+	out := flags.String("out", "", "archive to write, defaults to <corp>-<timestamp>.ngwaf-backup.tar.gz")
+	anonymize := flags.Bool("anonymize", false, "pseudonymize IPs, hostnames, URLs and emails, for sharing; keyed with NGWAF_ANONYMIZE_KEY, random when unset")
+	flags.Parse(args)
+
+	snapshot, err := fetch_corp_snapshot(ctx, sc, api, *corp)
+	if err != nil {
+		return err
+	}
+	if *anonymize {
+		anonymize_snapshot(&snapshot, new_anonymizer(os.Getenv("NGWAF_ANONYMIZE_KEY")))
+	}
+	created := time.Now()
+	if *out == "" {
+		*out = fmt.Sprintf("%s-%s.ngwaf-backup.tar.gz", *corp, created.UTC().Format("20060102T150405Z"))
+	}
+	if err := write_backup(*out, snapshot, created, *anonymize); err != nil {
+		return err
+	}
+	fmt.Println("wrote", *out)
+	return nil
+}
+
+func run_restore(ctx context.Context, sc sigsci.Client, api *APIClient, args []string) error {
+	flags := flag.NewFlagSet("restore", flag.ExitOnError)
+	corp := flags.String("corp", "", "corp to restore to, defaults to the corp of the backup")
+	dryRun := flags.Bool("dry-run", false, "only print the objects that would be recreated")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: restore [-corp corp] [-dry-run] backup.tar.gz")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("restore needs one backup archive")
+	}
+
+	manifest, backup, err := read_backup(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if manifest.Anonymized {
+		return fmt.Errorf("%s is an anonymized backup, restoring it would write pseudonyms into corp", flags.Arg(0))
+	}
+	if *corp == "" {
+		*corp = manifest.Corp
+	}
+	current, err := fetch_corp_snapshot(ctx, sc, api, *corp)
+	if err != nil {
+		return err
+	}
+
+	plan := restore_plan(*corp, backup, current)
+	fmt.Printf("backup of corp %s from %s: %d objects to recreate in corp %s\n", manifest.Corp, manifest.Created.Format(time.RFC3339), len(plan), *corp)
+	for _, request := range plan {
+		fmt.Printf("  + %s\n", request.Address)
+	}
+	if *dryRun || len(plan) == 0 {
+		return nil
+	}
+	return apply_requests(ctx, api, plan)
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBackupRestore(t *testing.T) {
+	_, sc, api := newFakeAPI(t, "testcorp")
+	ctx := context.Background()
+
+	snapshot, err := fetch_corp_snapshot(ctx, sc, api, "testcorp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "testcorp.ngwaf-backup.tar.gz")
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	if err := write_backup(path, snapshot, created, false); err != nil {
+		t.Fatal(err)
+	}
+	manifest, backup, err := read_backup(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest.FormatVersion != backupFormatVersion || manifest.Corp != "testcorp" || !manifest.Created.Equal(created) || manifest.Anonymized {
+		t.Errorf("unexpected manifest %+v", manifest)
+	}
+	if plan := restore_plan("testcorp", backup, snapshot); len(plan) != 0 {
+		t.Fatalf("nothing is missing, but the plan is %+v", plan)
+	}
+
+	for _, path := range []string{
+		"/v0/corps/testcorp/sites/www/lists/site.office-ips",
+		"/v0/corps/testcorp/sites/www/rules/61b2c3d4e5f60718293a4b5c",
+	} {
+		if _, err := api.Do(ctx, "DELETE", path, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	current, err := fetch_corp_snapshot(ctx, sc, api, "testcorp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan := restore_plan("testcorp", backup, current)
+	var paths []string
+	for _, request := range plan {
+		paths = append(paths, request.Method+" "+request.Path)
+	}
+	want := []string{
+		"POST /v0/corps/testcorp/sites/www/lists",
+		"POST /v0/corps/testcorp/sites/www/rules",
+	}
+	if !reflect.DeepEqual(paths, want) {
+		t.Fatalf("plan is %v, want %v", paths, want)
+	}
+
+	if err := apply_requests(ctx, api, plan); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := fetch_corp_snapshot(ctx, sc, api, "testcorp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan := restore_plan("testcorp", backup, restored); len(plan) != 0 {
+		t.Errorf("restored objects are still missing: %+v", plan)
+	}
+}
+
+func TestRestoreRefusesAnonymizedBackup(t *testing.T) {
+	server, sc, api := newFakeAPI(t, "testcorp")
+	ctx := context.Background()
+
+	snapshot, err := fetch_corp_snapshot(ctx, sc, api, "testcorp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	anonymize_snapshot(&snapshot, new_anonymizer("test-key"))
+	path := filepath.Join(t.TempDir(), "testcorp.ngwaf-backup.tar.gz")
+	if err := write_backup(path, snapshot, time.Now(), true); err != nil {
+		t.Fatal(err)
+	}
+
+	sent := len(server.Requests())
+	if err := run_restore(ctx, sc, api, []string{"-corp", "othercorp", path}); err == nil {
+		t.Fatal("restoring an anonymized backup succeeded")
+	}
+	if requests := server.Requests()[sent:]; len(requests) != 0 {
+		t.Errorf("restore sent %v", requests)
+	}
+}
+
+func TestRestoreMissingSite(t *testing.T) {
+	_, sc, api := newFakeAPI(t, "testcorp")
+	backup, err := fetch_corp_snapshot(context.Background(), sc, api, "testcorp")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plan := restore_plan("othercorp", backup, CorpSnapshot{Corp: "othercorp"})
+	// Everything is recreated, each object after the objects it may reference
+	rank := func(path string) int {
+		collection := filepath.Base(path)
+		switch {
+		case collection == "sites":
+			return 1
+		case collection == "tags" || collection == "lists":
+			if strings.Contains(path, "/sites/") {
+				return 2
+			}
+			return 0
+		case collection == "rules":
+			return 3
+		case collection == "alerts":
+			return 4
+		}
+		return 5
+	}
+	last := 0
+	for _, request := range plan {
+		if rank(request.Path) < last {
+			t.Errorf("%s %s comes too late", request.Method, request.Path)
+		}
+		last = rank(request.Path)
+	}
+	if plan[0].Path != "/v0/corps/othercorp/tags" && plan[0].Path != "/v0/corps/othercorp/lists" {
+		t.Errorf("plan starts with %s", plan[0].Path)
+	}
+}
@@ -0,0 +1,330 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// cdktfProviderConstraint is the sigsci provider version the generated CDKTF
+// projects bind against
+const cdktfProviderConstraint = "signalsciences/sigsci@>= 3.0.1"
+
+// cdktfHeader is the comment at the top of the generated programs
+const cdktfHeader = "Generated by ngwaf-terraformify. Run `cdktf get` to generate the provider bindings."
+
+// cdktfClassName is the name CDKTF gives the construct of resourceType,
+// e.g. SiteRule for sigsci_site_rule
+func cdktfClassName(resourceType string) string {
+	return pascalCase(strings.TrimPrefix(resourceType, "sigsci_"))
+}
+
+// pascalCase turns a snake_case Terraform name into PascalCase
+func pascalCase(name string) string {
+	var sb strings.Builder
+	for _, part := range strings.Split(name, "_") {
+		if part != "" {
+			sb.WriteString(strings.ToUpper(part[:1]) + part[1:])
+		}
+	}
+	return sb.String()
+}
+
+// camelCase turns a snake_case Terraform name into camelCase
+func camelCase(name string) string {
+	pascal := pascalCase(name)
+	if pascal == "" {
+		return pascal
+	}
+	return strings.ToLower(pascal[:1]) + pascal[1:]
+}
+
+// traversalString renders a reference as a Terraform interpolation, which
+// CDKTF accepts wherever a string token is expected
+func traversalString(traversal hcl.Traversal) string {
+	return "${" + strings.TrimSpace(string(hclwrite.TokensForTraversal(traversal).Bytes())) + "}"
+}
+
+// jsString quotes s as a TypeScript string literal
+func jsString(s string) string {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// write_cdktf_json writes the cdktf.json project file for language
+func write_cdktf_json(outputDir string, language string, app string) error {
+	project := map[string]interface{}{
+		"language":           language,
+		"app":                app,
+		"codeMakerOutput":    map[string]string{"typescript": ".gen", "go": "generated"}[language],
+		"terraformProviders": []string{cdktfProviderConstraint},
+		"terraformModules":   []string{},
+	}
+	var content bytes.Buffer
+	encoder := json.NewEncoder(&content)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(project); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(outputDir, "cdktf.json"), content.Bytes(), 0666); err != nil {
+		return fmt.Errorf("error writing cdktf.json: %v", err)
+	}
+	return nil
+}
+
+// write_cdktf_typescript writes main.ts, a CDKTF stack declaring every
+// import target as a construct that imports the existing object, and
+// cdktf.json
+func write_cdktf_typescript(imports *ImportSet, corp string, outputDir string) error {
+	var constructs bytes.Buffer
+	var secrets []secretVariable
+	// classes maps each construct class to its binding module
+	classes := map[string]string{}
+	for _, target := range imports.Targets {
+		config, err := secret_target_config(target, &secrets)
+		if err != nil {
+			return err
+		}
+		class := cdktfClassName(target.ResourceType)
+		classes[class] = strings.ReplaceAll(strings.TrimPrefix(target.ResourceType, "sigsci_"), "_", "-")
+
+		fmt.Fprintf(&constructs, "    new %s(this, %s, ", class, jsString(target.Name))
+		ts_object(&constructs, config, 2)
+		fmt.Fprintf(&constructs, ").importFrom(%s);\n\n", jsString(target.ID))
+	}
+
+	var sortedClasses []string
+	for class := range classes {
+		sortedClasses = append(sortedClasses, class)
+	}
+	sort.Strings(sortedClasses)
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// %s\n", cdktfHeader)
+	out.WriteString("import { Construct } from \"constructs\";\n")
+	out.WriteString("import { App, TerraformStack, TerraformVariable } from \"cdktf\";\n")
+	out.WriteString("import { SigsciProvider } from \"./.gen/providers/sigsci/provider\";\n")
+	for _, class := range sortedClasses {
+		fmt.Fprintf(&out, "import { %s } from \"./.gen/providers/sigsci/%s\";\n", class, classes[class])
+	}
+	out.WriteString("\nclass NgwafStack extends TerraformStack {\n")
+	out.WriteString("  constructor(scope: Construct, id: string) {\n")
+	out.WriteString("    super(scope, id);\n\n")
+	out.WriteString("    const ngwafEmail = new TerraformVariable(this, \"NGWAF_EMAIL\", { type: \"string\" });\n")
+	out.WriteString("    const ngwafToken = new TerraformVariable(this, \"NGWAF_TOKEN\", { type: \"string\", sensitive: true });\n\n")
+	out.WriteString("    new SigsciProvider(this, \"sigsci\", {\n")
+	fmt.Fprintf(&out, "      corp: %s,\n", jsString(corp))
+	out.WriteString("      email: ngwafEmail.stringValue,\n")
+	out.WriteString("      authToken: ngwafToken.stringValue,\n")
+	out.WriteString("    });\n\n")
+	out.Write(bytes.TrimSuffix(constructs.Bytes(), []byte("\n")))
+	out.WriteString("  }\n}\n\n")
+	out.WriteString("const app = new App();\n")
+	fmt.Fprintf(&out, "new NgwafStack(app, %s);\n", jsString(corp))
+	out.WriteString("app.synth();\n")
+
+	if err := os.WriteFile(filepath.Join(outputDir, "main.ts"), out.Bytes(), 0666); err != nil {
+		return fmt.Errorf("error writing main.ts: %v", err)
+	}
+	return write_cdktf_json(outputDir, "typescript", "npx ts-node main.ts")
+}
+
+// ts_object writes config as a TypeScript object literal indented by depth
+// levels
+func ts_object(out *bytes.Buffer, config *tfBlock, depth int) {
+	indent := strings.Repeat("  ", depth+1)
+	out.WriteString("{\n")
+	for _, attr := range config.Attrs {
+		fmt.Fprintf(out, "%s%s: %s,\n", indent, camelCase(attr.Name), ts_value(attr.Value))
+	}
+	for _, group := range group_blocks(config.Blocks) {
+		fmt.Fprintf(out, "%s%s: ", indent, camelCase(group.Type))
+		if singleBlocks[group.Type] {
+			ts_object(out, group.Bodies[0], depth+1)
+			out.WriteString(",\n")
+			continue
+		}
+		out.WriteString("[")
+		for i, body := range group.Bodies {
+			if i > 0 {
+				out.WriteString(", ")
+			}
+			ts_object(out, body, depth+1)
+		}
+		out.WriteString("],\n")
+	}
+	out.WriteString(strings.Repeat("  ", depth) + "}")
+}
+
+func ts_value(value interface{}) string {
+	switch value := value.(type) {
+	case string:
+		return jsString(value)
+	case int:
+		return strconv.Itoa(value)
+	case bool:
+		return strconv.FormatBool(value)
+	case []string:
+		var items []string
+		for _, item := range value {
+			items = append(items, jsString(item))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case hcl.Traversal:
+		return jsString(traversalString(value))
+	case tfVariable:
+		return fmt.Sprintf("new TerraformVariable(this, %s, { type: \"string\", sensitive: true }).stringValue", jsString(string(value)))
+	}
+	return "undefined"
+}
+
+// blockGroup is every nested block of one type, CDKTF takes them as one
+// property
+type blockGroup struct {
+	Type   string
+	Bodies []*tfBlock
+}
+
+// group_blocks groups blocks by type in order of first appearance
+func group_blocks(blocks []tfNestedBlock) []blockGroup {
+	var groups []blockGroup
+	index := map[string]int{}
+	for _, block := range blocks {
+		i, ok := index[block.Type]
+		if !ok {
+			i = len(groups)
+			index[block.Type] = i
+			groups = append(groups, blockGroup{Type: block.Type})
+		}
+		groups[i].Bodies = append(groups[i].Bodies, block.Body)
+	}
+	return groups
+}
+
+// cdktfGoModule is the module name of the CDKTF Go project template, the
+// generated bindings live under it
+const cdktfGoModule = "cdk.tf/go/stack"
+
+// write_cdktf_go writes main.go, the Go equivalent of write_cdktf_typescript,
+// and cdktf.json
+func write_cdktf_go(imports *ImportSet, corp string, outputDir string) error {
+	var constructs bytes.Buffer
+	var secrets []secretVariable
+	packages := map[string]bool{}
+	for _, target := range imports.Targets {
+		config, err := secret_target_config(target, &secrets)
+		if err != nil {
+			return err
+		}
+		class := cdktfClassName(target.ResourceType)
+		pkg := strings.ToLower(class)
+		packages[pkg] = true
+
+		fmt.Fprintf(&constructs, "%s.New%s(stack, jsii.String(%s), &%s.%sConfig", pkg, class, strconv.Quote(target.Name), pkg, class)
+		go_struct(&constructs, pkg, class, config)
+		fmt.Fprintf(&constructs, ").ImportFrom(jsii.String(%s), nil)\n\n", strconv.Quote(target.ID))
+	}
+
+	var sortedPackages []string
+	for pkg := range packages {
+		sortedPackages = append(sortedPackages, pkg)
+	}
+	sort.Strings(sortedPackages)
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// %s\n", cdktfHeader)
+	out.WriteString("package main\n\nimport (\n")
+	out.WriteString("\"github.com/aws/constructs-go/constructs/v10\"\n")
+	out.WriteString("\"github.com/aws/jsii-runtime-go\"\n")
+	out.WriteString("\"github.com/hashicorp/terraform-cdk-go/cdktf\"\n\n")
+	fmt.Fprintf(&out, "%q\n", cdktfGoModule+"/generated/signalsciences/sigsci/provider")
+	for _, pkg := range sortedPackages {
+		fmt.Fprintf(&out, "%q\n", cdktfGoModule+"/generated/signalsciences/sigsci/"+pkg)
+	}
+	out.WriteString(")\n\n")
+	out.WriteString("func NewNgwafStack(scope constructs.Construct, id string) cdktf.TerraformStack {\n")
+	out.WriteString("stack := cdktf.NewTerraformStack(scope, &id)\n\n")
+	out.WriteString("ngwafEmail := cdktf.NewTerraformVariable(stack, jsii.String(\"NGWAF_EMAIL\"), &cdktf.TerraformVariableConfig{Type: jsii.String(\"string\")})\n")
+	out.WriteString("ngwafToken := cdktf.NewTerraformVariable(stack, jsii.String(\"NGWAF_TOKEN\"), &cdktf.TerraformVariableConfig{Type: jsii.String(\"string\"), Sensitive: jsii.Bool(true)})\n\n")
+	out.WriteString("provider.NewSigsciProvider(stack, jsii.String(\"sigsci\"), &provider.SigsciProviderConfig{\n")
+	fmt.Fprintf(&out, "Corp: jsii.String(%s),\n", strconv.Quote(corp))
+	out.WriteString("Email: ngwafEmail.StringValue(),\n")
+	out.WriteString("AuthToken: ngwafToken.StringValue(),\n")
+	out.WriteString("})\n\n")
+	out.Write(constructs.Bytes())
+	out.WriteString("return stack\n}\n\n")
+	out.WriteString("func main() {\n")
+	out.WriteString("app := cdktf.NewApp(nil)\n")
+	fmt.Fprintf(&out, "NewNgwafStack(app, %s)\n", strconv.Quote(corp))
+	out.WriteString("app.Synth()\n}\n")
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return fmt.Errorf("error formatting main.go: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(outputDir, "main.go"), src, 0666); err != nil {
+		return fmt.Errorf("error writing main.go: %v", err)
+	}
+	return write_cdktf_json(outputDir, "go", "go run main.go")
+}
+
+// go_struct writes config as the body of a composite literal of
+// pkg.<typeName>. Nested block types are named after their parent.
+func go_struct(out *bytes.Buffer, pkg string, typeName string, config *tfBlock) {
+	out.WriteString("{\n")
+	for _, attr := range config.Attrs {
+		fmt.Fprintf(out, "%s: %s,\n", pascalCase(attr.Name), go_value(attr.Value))
+	}
+	for _, group := range group_blocks(config.Blocks) {
+		childType := typeName + pascalCase(group.Type)
+		fmt.Fprintf(out, "%s: ", pascalCase(group.Type))
+		if singleBlocks[group.Type] {
+			fmt.Fprintf(out, "&%s.%s", pkg, childType)
+			go_struct(out, pkg, childType, group.Bodies[0])
+			out.WriteString(",\n")
+			continue
+		}
+		fmt.Fprintf(out, "&[]*%s.%s{\n", pkg, childType)
+		for _, body := range group.Bodies {
+			go_struct(out, pkg, childType, body)
+			out.WriteString(",\n")
+		}
+		out.WriteString("},\n")
+	}
+	out.WriteString("}")
+}
+
+func go_value(value interface{}) string {
+	switch value := value.(type) {
+	case string:
+		return "jsii.String(" + strconv.Quote(value) + ")"
+	case int:
+		return "jsii.Number(" + strconv.Itoa(value) + ")"
+	case bool:
+		return "jsii.Bool(" + strconv.FormatBool(value) + ")"
+	case []string:
+		var items []string
+		for _, item := range value {
+			items = append(items, strconv.Quote(item))
+		}
+		return "jsii.Strings(" + strings.Join(items, ", ") + ")"
+	case hcl.Traversal:
+		return "jsii.String(" + strconv.Quote(traversalString(value)) + ")"
+	case tfVariable:
+		return fmt.Sprintf("cdktf.NewTerraformVariable(stack, jsii.String(%s), &cdktf.TerraformVariableConfig{Type: jsii.String(\"string\"), Sensitive: jsii.Bool(true)}).StringValue()", strconv.Quote(string(value)))
+	}
+	return "nil"
+}
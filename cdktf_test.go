@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestCdktfGolden(t *testing.T) {
+	for _, tc := range []struct {
+		output string
+		file   string
+	}{
+		{outputCdktfTS, "main.ts"},
+		{outputCdktfGo, "main.go"},
+	} {
+		t.Run(tc.output, func(t *testing.T) {
+			_, sc, api := newFakeAPI(t, "testcorp")
+			outputDir := t.TempDir()
+
+			opts := RunOptions{Format: formatHCL, Output: tc.output}
+			if err := terraformify_corp(context.Background(), sc, api, "testcorp", outputDir, opts, ""); err != nil {
+				t.Fatal(err)
+			}
+
+			assertGolden(t, filepath.Join(outputDir, "cdktf", tc.file), filepath.Join("testcorp", tc.output, tc.file))
+			assertGolden(t, filepath.Join(outputDir, "cdktf", "cdktf.json"), filepath.Join("testcorp", tc.output, "cdktf.json"))
+		})
+	}
+}
+
+func TestTargetConfigCoversEveryImport(t *testing.T) {
+	_, sc, api := newFakeAPI(t, "testcorp")
+
+	snapshot, err := fetch_corp_snapshot(context.Background(), sc, api, "testcorp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, target := range state_import_set(snapshot, t.TempDir()).Targets {
+		if _, err := target_config(target); err != nil {
+			t.Error(err)
+		}
+	}
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// cleanupRule describes how cleanup tidies the resources of one type
+type cleanupRule struct {
+	// Computed are the attributes and blocks the provider computes. Written
+	// in configuration they cause perpetual diffs.
+	Computed []string
+	// Order is the attribute order, matching the configuration the tool
+	// renders itself. Attributes not listed follow in their original order.
+	Order []string
+}
+
+// cleanupRules are the per resource type rules of cleanup, on top of
+// dropping id, null attributes and empty blocks everywhere
+var cleanupRules = map[string]cleanupRule{
+	"sigsci_corp_rule": {
+		Order: []string{"site_short_names", "type", "corp_scope", "group_operator", "enabled", "reason", "signal", "expiration", "requestlogging"},
+	},
+	"sigsci_site_rule": {
+		Order: []string{"site_short_name", "type", "group_operator", "enabled", "reason", "signal", "expiration", "requestlogging"},
+	},
+	"sigsci_corp_list": {
+		Order: []string{"name", "type", "description", "entries"},
+	},
+	"sigsci_site_list": {
+		Order: []string{"site_short_name", "name", "type", "description", "entries"},
+	},
+	"sigsci_corp_signal_tag": {
+		Computed: []string{"configurable", "informational", "needs_response"},
+		Order:    []string{"short_name", "description"},
+	},
+	"sigsci_site_signal_tag": {
+		Computed: []string{"configurable", "informational", "needs_response"},
+		Order:    []string{"site_short_name", "name", "description"},
+	},
+	"sigsci_site_alert": {
+		Order: []string{"site_short_name", "tag_name", "long_name", "interval", "threshold", "enabled", "action", "skip_notifications", "block_duration_seconds"},
+	},
+	"sigsci_site_agent_alert": {
+		Order: []string{"site_short_name", "tag_name", "long_name", "interval", "threshold", "enabled", "action", "skip_notifications"},
+	},
+	"sigsci_site": {
+		Computed: []string{"primary_agent_key"},
+		Order:    []string{"short_name", "display_name", "agent_level", "agent_anon_mode", "block_http_code", "block_duration_seconds", "block_redirect_url"},
+	},
+	"sigsci_site_integration": {
+		Order: []string{"site_short_name", "type", "url", "events"},
+	},
+	"sigsci_site_header_link": {
+		Order: []string{"site_short_name", "type", "name", "link_name", "link"},
+	},
+	"sigsci_site_templated_rule": {
+		Order: []string{"site_short_name", "name"},
+	},
+}
+
+// cleanupStats counts what cleanup removed, and the secrets it replaced
+type cleanupStats struct {
+	Nulls    int
+	Computed int
+	Blocks   int
+	Secrets  int
+}
+
+// cleanup_generated_config tidies the configuration Terraform generated for
+// imported resources: it drops null attributes, computed fields and blocks
+// left empty, puts attributes in the order of cleanupRules and replaces
+// secrets with the sensitive variables it returns, see redact_secrets
+func cleanup_generated_config(src []byte, filename string) ([]byte, []secretVariable, cleanupStats, error) {
+	var stats cleanupStats
+	var secrets []secretVariable
+	// hclwrite keeps no attribute order, so read it from the syntax tree,
+	// whose blocks are in the same order
+	syntaxFile, diags := hclsyntax.ParseConfig(src, filename, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, nil, stats, fmt.Errorf("error parsing %s: %v", filename, diags)
+	}
+	writeFile, diags := hclwrite.ParseConfig(src, filename, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, nil, stats, fmt.Errorf("error parsing %s: %v", filename, diags)
+	}
+
+	syntaxBlocks := syntaxFile.Body.(*hclsyntax.Body).Blocks
+	for i, block := range writeFile.Body().Blocks() {
+		if block.Type() != "resource" || len(block.Labels()) != 2 {
+			continue
+		}
+		rule := cleanupRules[block.Labels()[0]]
+		computed := map[string]bool{"id": true}
+		for _, name := range rule.Computed {
+			computed[name] = true
+		}
+		cleanup_body(block.Body(), syntaxBlocks[i].Body, computed, rule.Order, &stats)
+
+		target := ImportTarget{ResourceType: block.Labels()[0], Name: block.Labels()[1], ID: generated_import_id(src, syntaxBlocks[i])}
+		secrets = append(secrets, redact_generated_secrets(target, block.Body(), syntaxBlocks[i].Body)...)
+	}
+	stats.Secrets = len(secrets)
+	return hclwrite.Format(writeFile.Bytes()), secrets, stats, nil
+}
+
+// generated_import_id reads the import ID of a generated resource from the
+// comment Terraform writes above it
+func generated_import_id(src []byte, block *hclsyntax.Block) string {
+	before := bytes.TrimRight(src[:block.Range().Start.Byte], " \t\r\n")
+	comment := string(before[bytes.LastIndexByte(before, '\n')+1:])
+	quoted, ok := strings.CutPrefix(comment, "# __generated__ by Terraform from ")
+	if !ok {
+		return ""
+	}
+	id, err := strconv.Unquote(quoted)
+	if err != nil {
+		return ""
+	}
+	return id
+}
+
+// redact_generated_secrets replaces the secret string literals of a
+// generated resource, target, with variable references
+func redact_generated_secrets(target ImportTarget, body *hclwrite.Body, syntaxBody *hclsyntax.Body) []secretVariable {
+	if !secretResourceTypes[target.ResourceType] {
+		return nil
+	}
+	var names []string
+	for name := range body.Attributes() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	config := &tfBlock{}
+	for _, name := range names {
+		expr := syntaxBody.Attributes[name].Expr
+		if len(expr.Variables()) > 0 {
+			continue
+		}
+		value, diags := expr.Value(nil)
+		if diags.HasErrors() || value.IsNull() || !value.IsKnown() || value.Type() != cty.String {
+			continue
+		}
+		config.set(name, value.AsString())
+	}
+	secrets := redact_secrets(target, config)
+	for _, attr := range config.Attrs {
+		if variable, ok := attr.Value.(tfVariable); ok {
+			body.SetAttributeTraversal(attr.Name, variableTraversal(variable))
+		}
+	}
+	return secrets
+}
+
+// cleanup_body rewrites body, whose syntax tree is syntaxBody. computed and
+// order apply to the resource itself, not to its nested blocks.
+func cleanup_body(body *hclwrite.Body, syntaxBody *hclsyntax.Body, computed map[string]bool, order []string, stats *cleanupStats) {
+	var names []string
+	for name, attr := range syntaxBody.Attributes {
+		switch {
+		case computed[name]:
+			stats.Computed++
+		case isNullExpr(attr.Expr):
+			stats.Nulls++
+		default:
+			names = append(names, name)
+		}
+	}
+	position := map[string]int{}
+	for i, name := range order {
+		position[name] = i
+	}
+	sort.Slice(names, func(i, j int) bool {
+		pi, iListed := position[names[i]]
+		pj, jListed := position[names[j]]
+		if iListed != jListed {
+			return iListed
+		}
+		if iListed {
+			return pi < pj
+		}
+		return syntaxBody.Attributes[names[i]].SrcRange.Start.Byte < syntaxBody.Attributes[names[j]].SrcRange.Start.Byte
+	})
+
+	attrs := body.Attributes()
+	exprs := map[string]hclwrite.Tokens{}
+	for _, name := range names {
+		exprs[name] = attrs[name].Expr().BuildTokens(nil)
+	}
+	var blocks []*hclwrite.Block
+	for i, block := range body.Blocks() {
+		if computed[block.Type()] {
+			stats.Computed++
+			continue
+		}
+		cleanup_body(block.Body(), syntaxBody.Blocks[i].Body, nil, nil, stats)
+		if len(block.Body().Attributes()) == 0 && len(block.Body().Blocks()) == 0 {
+			stats.Blocks++
+			continue
+		}
+		blocks = append(blocks, block)
+	}
+
+	// Clear leaves the attributes and blocks indexed, remove them first
+	for name := range attrs {
+		body.RemoveAttribute(name)
+	}
+	for _, block := range body.Blocks() {
+		body.RemoveBlock(block)
+	}
+	body.Clear()
+	body.AppendNewline()
+	for _, name := range names {
+		body.SetAttributeRaw(name, exprs[name])
+	}
+	for _, block := range blocks {
+		body.AppendBlock(block)
+	}
+}
+
+func isNullExpr(expr hclsyntax.Expression) bool {
+	literal, ok := expr.(*hclsyntax.LiteralValueExpr)
+	return ok && literal.Val.IsNull()
+}
+
+// cleanup_file cleans up the configuration file at path in place, and
+// writes secrets.tf and secrets.auto.tfvars.example next to it for the
+// secrets it replaced
+func cleanup_file(path string) (cleanupStats, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return cleanupStats{}, fmt.Errorf("error reading %s: %v", path, err)
+	}
+	out, secrets, stats, err := cleanup_generated_config(src, path)
+	if err != nil {
+		return stats, err
+	}
+	if err := os.WriteFile(path, out, 0666); err != nil {
+		return stats, fmt.Errorf("error writing %s: %v", path, err)
+	}
+	// Secrets redacted in an earlier run are variables by now, so a second
+	// run writes nothing
+	return stats, write_secret_variables(secrets, filepath.Dir(path))
+}
+
+func run_cleanup(args []string) error {
+	flags := flag.NewFlagSet("cleanup", flag.ExitOnError)
+	file := flags.String("file", "generated.tf", "configuration generated by terraform plan -generate-config-out")
+	flags.Parse(args)
+
+	stats, err := cleanup_file(*file)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("removed %d null attributes, %d computed fields and %d empty blocks from %s\n", stats.Nulls, stats.Computed, stats.Blocks, *file)
+	if stats.Secrets > 0 {
+		fmt.Printf("replaced %d secrets with the variables in secrets.tf, set them in secrets.auto.tfvars\n", stats.Secrets)
+	}
+	return nil
+}
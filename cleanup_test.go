@@ -0,0 +1,45 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCleanupGolden(t *testing.T) {
+	src, err := os.ReadFile(filepath.Join("testdata", "cleanup", "generated.tf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "generated.tf")
+	if err := os.WriteFile(path, src, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := cleanup_file(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertGolden(t, path, filepath.Join("cleanup", "generated.tf"))
+	assertGolden(t, filepath.Join(filepath.Dir(path), "secrets.tf"), filepath.Join("cleanup", "secrets.tf"))
+	assertGolden(t, filepath.Join(filepath.Dir(path), "secrets.auto.tfvars.example"), filepath.Join("cleanup", "secrets.auto.tfvars.example"))
+
+	want := cleanupStats{Nulls: 8, Computed: 6, Blocks: 1, Secrets: 1}
+	if stats != want {
+		t.Errorf("stats = %+v, want %+v", stats, want)
+	}
+}
+
+func TestCleanupIsIdempotent(t *testing.T) {
+	src, err := os.ReadFile(filepath.Join("testdata", "golden", "cleanup", "generated.tf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, secrets, stats, err := cleanup_generated_config(src, "generated.tf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != string(src) || len(secrets) > 0 || stats != (cleanupStats{}) {
+		t.Errorf("cleanup changed clean configuration, stats %+v:\n%s", stats, out)
+	}
+}
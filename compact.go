@@ -0,0 +1,226 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// compactResourceName is the name of the single for_each resource written
+// per resource type in compact mode
+const compactResourceName = "this"
+
+// blockShape is the union of the attributes and nested blocks of every
+// instance of a block, in order of first appearance
+type blockShape struct {
+	attrs     []string
+	attrCount map[string]int
+	blocks    []string
+	children  map[string]*blockShape
+	// instances is the number of blocks merged into the shape
+	instances int
+}
+
+func newBlockShape() *blockShape {
+	return &blockShape{attrCount: map[string]int{}, children: map[string]*blockShape{}}
+}
+
+func (s *blockShape) merge(config *tfBlock) {
+	s.instances++
+	for _, attr := range config.Attrs {
+		if s.attrCount[attr.Name] == 0 {
+			s.attrs = append(s.attrs, attr.Name)
+		}
+		s.attrCount[attr.Name]++
+	}
+	for _, block := range config.Blocks {
+		child, ok := s.children[block.Type]
+		if !ok {
+			child = newBlockShape()
+			s.children[block.Type] = child
+			s.blocks = append(s.blocks, block.Type)
+		}
+		child.merge(block.Body)
+	}
+}
+
+// block_object turns a block into the object a for_each resource reads its
+// arguments from. Nested blocks become lists of objects, including the
+// blocks allowed only once, so every nested block is a dynamic block.
+func block_object(config *tfBlock) hclwrite.Tokens {
+	values := map[string]hclwrite.Tokens{}
+	for _, attr := range config.Attrs {
+		switch value := attr.Value.(type) {
+		case string:
+			values[attr.Name] = hclwrite.TokensForValue(cty.StringVal(value))
+		case int:
+			values[attr.Name] = hclwrite.TokensForValue(cty.NumberIntVal(int64(value)))
+		case bool:
+			values[attr.Name] = hclwrite.TokensForValue(cty.BoolVal(value))
+		case []string:
+			values[attr.Name] = hclwrite.TokensForValue(stringListVal(value))
+		case hcl.Traversal:
+			values[attr.Name] = hclwrite.TokensForTraversal(value)
+		case hclwrite.Tokens:
+			values[attr.Name] = value
+		case tfVariable:
+			values[attr.Name] = hclwrite.TokensForTraversal(variableTraversal(value))
+		}
+	}
+	for _, group := range group_blocks(config.Blocks) {
+		var items []hclwrite.Tokens
+		for _, body := range group.Bodies {
+			items = append(items, block_object(body))
+		}
+		values[group.Type] = hclwrite.TokensForTuple(items)
+	}
+	return object_tokens(values)
+}
+
+// object_tokens renders an object constructor with sorted keys
+func object_tokens(values map[string]hclwrite.Tokens) hclwrite.Tokens {
+	var keys []string
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var attrs []hclwrite.ObjectAttrTokens
+	for _, key := range keys {
+		name := hclwrite.TokensForIdentifier(key)
+		if !hclsyntax.ValidIdentifier(key) {
+			name = hclwrite.TokensForValue(cty.StringVal(key))
+		}
+		attrs = append(attrs, hclwrite.ObjectAttrTokens{Name: name, Value: values[key]})
+	}
+	return hclwrite.TokensForObject(attrs)
+}
+
+// render_shape writes the arguments of a for_each resource, or of the
+// content of a dynamic block, reading them from the object named by source
+// (each.value or a dynamic block iterator's value)
+func render_shape(body *hclwrite.Body, shape *blockShape, source hcl.Traversal, iteratorPrefix string) {
+	for _, name := range shape.attrs {
+		value := append(append(hcl.Traversal{}, source...), hcl.TraverseAttr{Name: name})
+		if shape.attrCount[name] == shape.instances {
+			body.SetAttributeTraversal(name, value)
+			continue
+		}
+		// Attributes some instances leave unset
+		body.SetAttributeRaw(name, try_tokens(value, hclwrite.Tokens{{Type: hclsyntax.TokenIdent, Bytes: []byte("null")}}))
+	}
+	for _, blockType := range shape.blocks {
+		iterator := strings.TrimPrefix(iteratorPrefix+"_"+blockType, "_")
+		dynamic := body.AppendNewBlock("dynamic", []string{blockType}).Body()
+		items := append(append(hcl.Traversal{}, source...), hcl.TraverseAttr{Name: blockType})
+		dynamic.SetAttributeRaw("for_each", try_tokens(items, hclwrite.Tokens{
+			{Type: hclsyntax.TokenOBrack, Bytes: []byte("[")},
+			{Type: hclsyntax.TokenCBrack, Bytes: []byte("]")},
+		}))
+		if iterator != blockType {
+			dynamic.SetAttributeTraversal("iterator", hcl.Traversal{hcl.TraverseRoot{Name: iterator}})
+		}
+		content := dynamic.AppendNewBlock("content", nil).Body()
+		render_shape(content, shape.children[blockType], hcl.Traversal{
+			hcl.TraverseRoot{Name: iterator},
+			hcl.TraverseAttr{Name: "value"},
+		}, iterator)
+	}
+}
+
+// try_tokens renders try(<traversal>, <fallback>)
+func try_tokens(traversal hcl.Traversal, fallback hclwrite.Tokens) hclwrite.Tokens {
+	return hclwrite.TokensForFunctionCall("try", hclwrite.TokensForTraversal(traversal), fallback)
+}
+
+// write_compact_import_blocks writes fileName with, per resource type, a
+// local map of name to ID, a local map of name to configuration, one
+// for_each import block and one for_each resource. It needs Terraform 1.7.
+// listFiles, when set, is the format list entries are externalized in.
+// Secrets are replaced with the sensitive variables of secrets.tf.
+func write_compact_import_blocks(imports *ImportSet, fileName string, providerAlias string, listFiles string) error {
+	var types []string
+	byType := map[string][]ImportTarget{}
+	for _, target := range imports.Targets {
+		if _, ok := byType[target.ResourceType]; !ok {
+			types = append(types, target.ResourceType)
+		}
+		byType[target.ResourceType] = append(byType[target.ResourceType], target)
+	}
+
+	var secrets []secretVariable
+	file := hclwrite.NewEmptyFile()
+	locals := file.Body().AppendNewBlock("locals", nil).Body()
+	file.Body().AppendNewline()
+
+	for _, resourceType := range types {
+		ids := map[string]cty.Value{}
+		configs := map[string]hclwrite.Tokens{}
+		shape := newBlockShape()
+		for _, target := range byType[resourceType] {
+			config, err := secret_target_config(target, &secrets)
+			if err != nil {
+				return err
+			}
+			if listFiles != "" && isListResource(resourceType) {
+				if err := externalize_list_entries(config, filepath.Dir(fileName), target.Name, listFiles); err != nil {
+					return err
+				}
+			}
+			ids[target.Name] = cty.StringVal(target.ID)
+			configs[target.Name] = block_object(config)
+			shape.merge(config)
+		}
+		locals.SetAttributeValue(resourceType+"_ids", cty.ObjectVal(ids))
+		locals.SetAttributeRaw(resourceType, object_tokens(configs))
+
+		importBlock := file.Body().AppendNewBlock("import", nil).Body()
+		importBlock.SetAttributeTraversal("for_each", hcl.Traversal{
+			hcl.TraverseRoot{Name: "local"},
+			hcl.TraverseAttr{Name: resourceType + "_ids"},
+		})
+		to := hclwrite.TokensForTraversal(hcl.Traversal{
+			hcl.TraverseRoot{Name: resourceType},
+			hcl.TraverseAttr{Name: compactResourceName},
+		})
+		to = append(to, &hclwrite.Token{Type: hclsyntax.TokenOBrack, Bytes: []byte("[")})
+		to = append(to, hclwrite.TokensForTraversal(hcl.Traversal{
+			hcl.TraverseRoot{Name: "each"},
+			hcl.TraverseAttr{Name: "key"},
+		})...)
+		to = append(to, &hclwrite.Token{Type: hclsyntax.TokenCBrack, Bytes: []byte("]")})
+		importBlock.SetAttributeRaw("to", to)
+		importBlock.SetAttributeTraversal("id", hcl.Traversal{
+			hcl.TraverseRoot{Name: "each"},
+			hcl.TraverseAttr{Name: "value"},
+		})
+		file.Body().AppendNewline()
+
+		resource := file.Body().AppendNewBlock("resource", []string{resourceType, compactResourceName}).Body()
+		resource.SetAttributeTraversal("for_each", hcl.Traversal{
+			hcl.TraverseRoot{Name: "local"},
+			hcl.TraverseAttr{Name: resourceType},
+		})
+		render_shape(resource, shape, hcl.Traversal{
+			hcl.TraverseRoot{Name: "each"},
+			hcl.TraverseAttr{Name: "value"},
+		}, "")
+		file.Body().AppendNewline()
+	}
+
+	if providerAlias != "" {
+		set_provider_alias(file.Body(), "import", providerAlias)
+		set_provider_alias(file.Body(), "resource", providerAlias)
+	}
+
+	if err := write_terraform_config_file(file, fileName); err != nil {
+		return fmt.Errorf("error writing compact imports: %v", err)
+	}
+	return write_secret_variables(secrets, filepath.Dir(fileName))
+}
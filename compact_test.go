@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+func TestCompactGolden(t *testing.T) {
+	_, sc, api := newFakeAPI(t, "testcorp")
+	outputDir := t.TempDir()
+
+	opts := RunOptions{Format: formatHCL, Output: outputTerraform, Compact: true}
+	if err := terraformify_corp(context.Background(), sc, api, "testcorp", outputDir, opts, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(outputDir, "import.tf")
+	assertGolden(t, path, filepath.Join("testcorp", "compact", "import.tf"))
+
+	src, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, diags := hclsyntax.ParseConfig(src, path, hcl.InitialPos); diags.HasErrors() {
+		t.Fatal(diags)
+	}
+}
+
+func TestCompactKeepsObjectsInState(t *testing.T) {
+	_, sc, api := newFakeAPI(t, "testcorp")
+	outputDir := t.TempDir()
+
+	state := `{"version": 4, "resources": [
+		{"type": "sigsci_site", "name": "www", "instances": [{"attributes": {"id": "www"}}]}
+	]}`
+	if err := os.WriteFile(filepath.Join(outputDir, "terraform.tfstate"), []byte(state), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := RunOptions{Format: formatHCL, Output: outputTerraform, Compact: true}
+	if err := terraformify_corp(context.Background(), sc, api, "testcorp", outputDir, opts, ""); err != nil {
+		t.Fatal(err)
+	}
+	assertGolden(t, filepath.Join(outputDir, "import.tf"), filepath.Join("testcorp", "compact", "import.tf"))
+}
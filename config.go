@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// Config lists the corps to process in a single run
+type Config struct {
+	Corps []CorpConfig `json:"corps"`
+}
+
+// CorpConfig describes one corp and where its configuration is written.
+// Credentials can be given inline or, preferably, as the names of
+// environment variables holding them.
+type CorpConfig struct {
+	Name          string `json:"name"`
+	Email         string `json:"email,omitempty"`
+	EmailEnv      string `json:"email_env,omitempty"`
+	Token         string `json:"token,omitempty"`
+	TokenEnv      string `json:"token_env,omitempty"`
+	OutputDir     string `json:"output_dir,omitempty"`
+	ProviderAlias string `json:"provider_alias,omitempty"`
+}
+
+// LoadConfig reads and validates a corps config file. Relative output
+// directories are resolved against the directory of the config file.
+func LoadConfig(path string) (Config, error) {
+	var cfg Config
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, fmt.Errorf("error reading config file: %v", err)
+	}
+	if err := json.Unmarshal(content, &cfg); err != nil {
+		return cfg, fmt.Errorf("error parsing config file: %v", err)
+	}
+	if len(cfg.Corps) == 0 {
+		return cfg, fmt.Errorf("no corps listed in %s", path)
+	}
+
+	seenDirs := map[string]string{}
+	for i := range cfg.Corps {
+		corp := &cfg.Corps[i]
+		if corp.Name == "" {
+			return cfg, fmt.Errorf("corp #%d has no name", i+1)
+		}
+		if corp.OutputDir == "" {
+			corp.OutputDir = corp.Name
+		}
+		if !filepath.IsAbs(corp.OutputDir) {
+			corp.OutputDir = filepath.Join(filepath.Dir(path), corp.OutputDir)
+		}
+		if corp.ProviderAlias == "" {
+			corp.ProviderAlias = sanitizeTfId(corp.Name)
+		}
+		if !hclsyntax.ValidIdentifier(corp.ProviderAlias) {
+			return cfg, fmt.Errorf("corp %s: invalid provider alias %q", corp.Name, corp.ProviderAlias)
+		}
+		if other, ok := seenDirs[corp.OutputDir]; ok {
+			return cfg, fmt.Errorf("corps %s and %s share the output directory %s", other, corp.Name, corp.OutputDir)
+		}
+		seenDirs[corp.OutputDir] = corp.Name
+	}
+	return cfg, nil
+}
+
+// Credentials resolves the email and token for the corp
+func (c CorpConfig) Credentials() (string, string, error) {
+	email, token := c.Email, c.Token
+	if c.EmailEnv != "" {
+		email = os.Getenv(c.EmailEnv)
+	}
+	if c.TokenEnv != "" {
+		token = os.Getenv(c.TokenEnv)
+	}
+	if email == "" || token == "" {
+		return "", "", fmt.Errorf("corp %s: missing email or token", c.Name)
+	}
+	return email, token, nil
+}
+
+// set_provider_alias sets the provider meta-argument of every blockType
+// block in body to the aliased sigsci provider
+func set_provider_alias(body *hclwrite.Body, blockType string, alias string) {
+	for _, block := range body.Blocks() {
+		if block.Type() != blockType {
+			continue
+		}
+		block.Body().SetAttributeTraversal("provider", hcl.Traversal{
+			hcl.TraverseRoot{Name: "sigsci"},
+			hcl.TraverseAttr{Name: alias},
+		})
+	}
+}
+
+// Corp returns the config entry for the named corp
+func (cfg Config) Corp(name string) (CorpConfig, bool) {
+	for _, corp := range cfg.Corps {
+		if corp.Name == name {
+			return corp, true
+		}
+	}
+	return CorpConfig{}, false
+}
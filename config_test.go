@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"Documents/mygit/ngwaf-terraformify/internal/fakeapi"
+)
+
+func writeConfig(t *testing.T, dir string, cfg string) string {
+	t.Helper()
+	path := filepath.Join(dir, "corps.json")
+	if err := os.WriteFile(path, []byte(cfg), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfigDefaults(t *testing.T) {
+	dir := t.TempDir()
+	path := writeConfig(t, dir, `{"corps": [{"name": "acme-prod", "email": "a@example.com", "token": "x"}]}`)
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	corp := cfg.Corps[0]
+	if corp.OutputDir != filepath.Join(dir, "acme-prod") {
+		t.Errorf("got output dir %q", corp.OutputDir)
+	}
+	if corp.ProviderAlias != "acme-prod" {
+		t.Errorf("got provider alias %q", corp.ProviderAlias)
+	}
+}
+
+func TestLoadConfigRejectsSharedOutputDir(t *testing.T) {
+	path := writeConfig(t, t.TempDir(), `{"corps": [
+		{"name": "prod", "output_dir": "out"},
+		{"name": "staging", "output_dir": "out"}
+	]}`)
+
+	if _, err := LoadConfig(path); err == nil {
+		t.Error("expected an error for corps sharing an output directory")
+	}
+}
+
+func TestCorpConfigCredentialsFromEnv(t *testing.T) {
+	t.Setenv("PROD_EMAIL", "prod@example.com")
+	t.Setenv("PROD_TOKEN", "secret")
+
+	email, token, err := CorpConfig{Name: "prod", EmailEnv: "PROD_EMAIL", TokenEnv: "PROD_TOKEN"}.Credentials()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if email != "prod@example.com" || token != "secret" {
+		t.Errorf("got %q %q", email, token)
+	}
+
+	if _, _, err := (CorpConfig{Name: "prod", TokenEnv: "UNSET_TOKEN"}).Credentials(); err == nil {
+		t.Error("expected an error for missing credentials")
+	}
+}
+
+func TestTerraformifyCorps(t *testing.T) {
+	fixture, err := fakeapi.LoadFixture(filepath.Join("testdata", "fixtures", "testcorp.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixture["corps/stagingcorp/sites"] = []json.RawMessage{json.RawMessage(`{"name": "www"}`)}
+	_, _, api := newFakeAPIFromFixture(t, fixture)
+
+	dir := t.TempDir()
+	path := writeConfig(t, dir, `{"corps": [
+		{"name": "testcorp", "email": "a@example.com", "token": "x", "output_dir": "prod", "provider_alias": "prod"},
+		{"name": "stagingcorp", "email": "b@example.com", "token": "y", "output_dir": "staging", "provider_alias": "staging"}
+	]}`)
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := terraformify_corps(context.Background(), api, cfg, terraformOptions); err != nil {
+		t.Fatal(err)
+	}
+
+	assertGolden(t, filepath.Join(dir, "staging", "import.tf"), filepath.Join("multicorp", "staging", "import.tf"))
+	assertGolden(t, filepath.Join(dir, "staging", "providers.tf"), filepath.Join("multicorp", "staging", "providers.tf"))
+	assertGolden(t, filepath.Join(dir, "staging", "variables.tf"), filepath.Join("multicorp", "staging", "variables.tf"))
+
+	prod, err := os.ReadFile(filepath.Join(dir, "prod", "import.tf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.Count(string(prod), "provider = sigsci.prod"), strings.Count(string(prod), "import {"); got != want {
+		t.Errorf("%d of %d prod import blocks use the prod provider", got, want)
+	}
+}
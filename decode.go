@@ -0,0 +1,693 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/tryfunc"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	sigsci "github.com/signalsciences/go-sigsci"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+	"github.com/zclconf/go-cty/cty/gocty"
+)
+
+// apiResource is a sigsci resource of a configuration decoded into the
+// go-sigsci body type the API takes for it
+type apiResource struct {
+	Type string
+	// Name is the resource name, or the instance key of for_each resources
+	Name string
+	// Address is the Terraform address of the resource instance
+	Address string
+	// Site is the site_short_name, empty for corp objects and sites
+	Site string
+	// Body is a sigsci.CreateCorpRuleBody, CreateSiteRuleBody,
+	// CreateListBody, CreateSignalTagBody, CustomAlertBody, IntegrationBody,
+	// HeaderLinkBody or CreateSiteBody, or a
+	// ResponseSiteLegacyTemplatedRuleBody
+	Body interface{}
+	// ImportID is the ID of the import block targeting the resource, empty
+	// without one
+	ImportID string
+	// Err is set, and Body is not, when the resource could not be decoded
+	Err error
+}
+
+// decodableResourceTypes are the resource types decode_resources converts
+var decodableResourceTypes = map[string]bool{
+	"sigsci_site":                true,
+	"sigsci_site_templated_rule": true,
+	"sigsci_corp_rule":           true,
+	"sigsci_site_rule":           true,
+	"sigsci_corp_list":           true,
+	"sigsci_site_list":           true,
+	"sigsci_corp_signal_tag":     true,
+	"sigsci_site_signal_tag":     true,
+	"sigsci_site_alert":          true,
+	"sigsci_site_agent_alert":    true,
+	"sigsci_site_integration":    true,
+	"sigsci_site_header_link":    true,
+}
+
+// hclScope is a block body with the context its expressions evaluate in
+type hclScope struct {
+	body *hclsyntax.Body
+	ctx  *hcl.EvalContext
+}
+
+// resourceInstance is a resource block, or one instance of a for_each
+// resource block
+type resourceInstance struct {
+	resourceType string
+	name         string
+	address      string
+	scope        hclScope
+}
+
+// resourceDecoder converts sigsci resources to API bodies, collecting the
+// problems of a resource in diags
+type resourceDecoder struct {
+	diags hcl.Diagnostics
+}
+
+// configFile is the source of a configuration file
+type configFile struct {
+	Path string
+	Src  []byte
+}
+
+func read_config_files(paths []string) ([]configFile, error) {
+	var files []configFile
+	for _, path := range paths {
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %v", path, err)
+		}
+		files = append(files, configFile{Path: path, Src: src})
+	}
+	return files, nil
+}
+
+// decode_resources decodes the sigsci resources of the configuration files
+// of one module. Locals, var.* (read from TF_VAR_* like Terraform does),
+// path.module, for_each, dynamic blocks and the functions the tool renders
+// are evaluated. References to list and signal resources resolve to the API
+// ID in knownIDs, keyed by resource type and name, or to the ID the API
+// derives from their name. Resource types without an API body are skipped.
+// A resource that fails to decode, e.g. for an unset variable, carries the
+// error instead of a body.
+func decode_resources(files []configFile, moduleDir string, knownIDs map[string]string) ([]apiResource, error) {
+	var blocks []*hclsyntax.Block
+	for _, configFile := range files {
+		file, diags := hclsyntax.ParseConfig(configFile.Src, configFile.Path, hcl.InitialPos)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("error parsing %s: %v", configFile.Path, diags)
+		}
+		blocks = append(blocks, file.Body.(*hclsyntax.Body).Blocks...)
+	}
+
+	ctx := &hcl.EvalContext{
+		Variables: map[string]cty.Value{
+			"var":  tfVarValues(blocks),
+			"path": cty.ObjectVal(map[string]cty.Value{"module": cty.StringVal(moduleDir)}),
+		},
+		Functions: decodeFunctions(),
+	}
+
+	locals := map[string]cty.Value{}
+	for _, block := range blocks {
+		if block.Type != "locals" {
+			continue
+		}
+		for name, attr := range block.Body.Attributes {
+			value, diags := attr.Expr.Value(ctx)
+			if diags.HasErrors() {
+				return nil, fmt.Errorf("error evaluating local.%s: %v", name, diags)
+			}
+			locals[name] = value
+		}
+	}
+	ctx.Variables["local"] = cty.ObjectVal(locals)
+
+	importIDs, err := decode_import_ids(blocks, ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var instances []resourceInstance
+	for _, block := range blocks {
+		if block.Type != "resource" || len(block.Labels) != 2 || !decodableResourceTypes[block.Labels[0]] {
+			continue
+		}
+		expanded, err := expand_resource(block, ctx)
+		if err != nil {
+			return nil, err
+		}
+		instances = append(instances, expanded...)
+	}
+
+	// References to lists and signals need their IDs before anything else
+	// is decoded
+	ids := map[string]map[string]cty.Value{}
+	forEach := map[string]bool{}
+	for _, instance := range instances {
+		if !isListResource(instance.resourceType) && !strings.HasSuffix(instance.resourceType, "_signal_tag") {
+			continue
+		}
+		id, ok := knownIDs[instance.resourceType+"."+instance.name]
+		if !ok {
+			decoder := &resourceDecoder{}
+			id = derivedID(instance.resourceType, decoder, instance.scope)
+			if decoder.diags.HasErrors() {
+				return nil, fmt.Errorf("error decoding %s: %v", instance.address, decoder.diags)
+			}
+		}
+		if ids[instance.resourceType] == nil {
+			ids[instance.resourceType] = map[string]cty.Value{}
+		}
+		ids[instance.resourceType][instance.name] = cty.ObjectVal(map[string]cty.Value{"id": cty.StringVal(id)})
+		forEach[instance.resourceType] = instance.address != instance.resourceType+"."+instance.name
+	}
+	for resourceType, byName := range ids {
+		if forEach[resourceType] {
+			ctx.Variables[resourceType] = cty.ObjectVal(map[string]cty.Value{compactResourceName: cty.ObjectVal(byName)})
+		} else {
+			ctx.Variables[resourceType] = cty.ObjectVal(byName)
+		}
+	}
+
+	var resources []apiResource
+	for _, instance := range instances {
+		decoder := &resourceDecoder{}
+		site, body := decoder.decode(instance.resourceType, instance.scope)
+		resource := apiResource{
+			Type:     instance.resourceType,
+			Name:     instance.name,
+			Address:  instance.address,
+			Site:     site,
+			Body:     body,
+			ImportID: importIDs[instance.resourceType+"."+instance.name],
+		}
+		if decoder.diags.HasErrors() {
+			resource.Body = nil
+			resource.Err = fmt.Errorf("error decoding %s: %v", instance.address, decoder.diags)
+		}
+		resources = append(resources, resource)
+	}
+	return resources, nil
+}
+
+// decode_import_ids maps the resources import blocks target, as resource
+// type and name or for_each instance key, to the imported ID
+func decode_import_ids(blocks []*hclsyntax.Block, ctx *hcl.EvalContext) (map[string]string, error) {
+	ids := map[string]string{}
+	for _, block := range blocks {
+		if block.Type != "import" {
+			continue
+		}
+		to, hasTo := block.Body.Attributes["to"]
+		id, hasID := block.Body.Attributes["id"]
+		if !hasTo || !hasID {
+			return nil, fmt.Errorf("%s: import block needs to and id", block.DefRange())
+		}
+
+		scopes := []*hcl.EvalContext{ctx}
+		if forEachAttr, ok := block.Body.Attributes["for_each"]; ok {
+			forEach, diags := forEachAttr.Expr.Value(ctx)
+			if diags.HasErrors() {
+				return nil, fmt.Errorf("error evaluating for_each of import: %v", diags)
+			}
+			scopes = nil
+			for _, element := range sortedElements(forEach) {
+				child := ctx.NewChild()
+				child.Variables = map[string]cty.Value{
+					"each": cty.ObjectVal(map[string]cty.Value{"key": element.key, "value": element.value}),
+				}
+				scopes = append(scopes, child)
+			}
+		}
+
+		for _, scope := range scopes {
+			address, err := import_target_address(to.Expr, scope)
+			if err != nil {
+				return nil, err
+			}
+			value, diags := id.Expr.Value(scope)
+			if diags.HasErrors() {
+				return nil, fmt.Errorf("error evaluating import id of %s: %v", address, diags)
+			}
+			value, err = convert.Convert(value, cty.String)
+			if err != nil || value.IsNull() || !value.IsKnown() {
+				return nil, fmt.Errorf("import id of %s is not a string", address)
+			}
+			ids[address] = value.AsString()
+		}
+	}
+	return ids, nil
+}
+
+// import_target_address returns the resource type and name, or for_each
+// instance key, an import block's to refers to
+func import_target_address(expr hclsyntax.Expression, ctx *hcl.EvalContext) (string, error) {
+	if index, ok := expr.(*hclsyntax.IndexExpr); ok {
+		traversal, diags := hcl.AbsTraversalForExpr(index.Collection)
+		if diags.HasErrors() || len(traversal) != 2 {
+			return "", fmt.Errorf("%s: unsupported import target", expr.Range())
+		}
+		key, diags := index.Key.Value(ctx)
+		if diags.HasErrors() || key.Type() != cty.String || !key.IsKnown() {
+			return "", fmt.Errorf("%s: unsupported import target key", expr.Range())
+		}
+		return traversal.RootName() + "." + key.AsString(), nil
+	}
+	traversal, diags := hcl.AbsTraversalForExpr(expr)
+	if diags.HasErrors() || len(traversal) != 2 {
+		return "", fmt.Errorf("%s: unsupported import target", expr.Range())
+	}
+	name, ok := traversal[1].(hcl.TraverseAttr)
+	if !ok {
+		return "", fmt.Errorf("%s: unsupported import target", expr.Range())
+	}
+	return traversal.RootName() + "." + name.Name, nil
+}
+
+// tfVarValues are the input variables referenced by blocks, with the values
+// set in the environment. Unset ones are unknown, so only the attributes
+// depending on them fail to decode.
+func tfVarValues(blocks []*hclsyntax.Block) cty.Value {
+	values := map[string]cty.Value{}
+	var visit func(body *hclsyntax.Body)
+	visit = func(body *hclsyntax.Body) {
+		for _, attr := range body.Attributes {
+			for _, traversal := range attr.Expr.Variables() {
+				if traversal.RootName() != "var" || len(traversal) < 2 {
+					continue
+				}
+				if step, ok := traversal[1].(hcl.TraverseAttr); ok {
+					values[step.Name] = cty.UnknownVal(cty.String)
+					if value, set := os.LookupEnv("TF_VAR_" + step.Name); set {
+						values[step.Name] = cty.StringVal(value)
+					}
+				}
+			}
+		}
+		for _, block := range body.Blocks {
+			visit(block.Body)
+		}
+	}
+	for _, block := range blocks {
+		visit(block.Body)
+	}
+	return cty.ObjectVal(values)
+}
+
+// decodeFunctions are the functions of the configuration the tool renders
+func decodeFunctions() map[string]function.Function {
+	return map[string]function.Function{
+		"file": function.New(&function.Spec{
+			Params: []function.Parameter{{Name: "path", Type: cty.String}},
+			Type:   function.StaticReturnType(cty.String),
+			Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+				// Relative to the working directory, like Terraform
+				content, err := os.ReadFile(args[0].AsString())
+				if err != nil {
+					return cty.NilVal, err
+				}
+				return cty.StringVal(string(content)), nil
+			},
+		}),
+		"jsondecode": stdlib.JSONDecodeFunc,
+		"split":      stdlib.SplitFunc,
+		"trimspace":  stdlib.TrimSpaceFunc,
+		"try":        tryfunc.TryFunc,
+	}
+}
+
+// expand_resource returns the instances of a resource block, one per
+// for_each element
+func expand_resource(block *hclsyntax.Block, ctx *hcl.EvalContext) ([]resourceInstance, error) {
+	resourceType, name := block.Labels[0], block.Labels[1]
+	forEachAttr, ok := block.Body.Attributes["for_each"]
+	if !ok {
+		return []resourceInstance{{
+			resourceType: resourceType,
+			name:         name,
+			address:      resourceType + "." + name,
+			scope:        hclScope{body: block.Body, ctx: ctx},
+		}}, nil
+	}
+
+	forEach, diags := forEachAttr.Expr.Value(ctx)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("error evaluating for_each of %s.%s: %v", resourceType, name, diags)
+	}
+	var instances []resourceInstance
+	for _, element := range sortedElements(forEach) {
+		child := ctx.NewChild()
+		child.Variables = map[string]cty.Value{
+			"each": cty.ObjectVal(map[string]cty.Value{"key": element.key, "value": element.value}),
+		}
+		key := element.key.AsString()
+		instances = append(instances, resourceInstance{
+			resourceType: resourceType,
+			name:         key,
+			address:      fmt.Sprintf("%s.%s[%q]", resourceType, name, key),
+			scope:        hclScope{body: block.Body, ctx: child},
+		})
+	}
+	return instances, nil
+}
+
+type collectionElement struct {
+	key   cty.Value
+	value cty.Value
+}
+
+// sortedElements returns the elements of a map, object or list, maps and
+// objects sorted by key like Terraform's for_each
+func sortedElements(collection cty.Value) []collectionElement {
+	if collection.IsNull() || !collection.IsKnown() || !collection.CanIterateElements() {
+		return nil
+	}
+	var elements []collectionElement
+	for it := collection.ElementIterator(); it.Next(); {
+		key, value := it.Element()
+		elements = append(elements, collectionElement{key: key, value: value})
+	}
+	if collection.Type().IsObjectType() || collection.Type().IsMapType() {
+		sort.Slice(elements, func(i, j int) bool {
+			return elements[i].key.AsString() < elements[j].key.AsString()
+		})
+	}
+	return elements
+}
+
+// derivedID is the ID the API gives a list or signal, "site." or "corp."
+// and the lowercased name with spaces replaced by dashes
+func derivedID(resourceType string, d *resourceDecoder, s hclScope) string {
+	var name string
+	switch resourceType {
+	case "sigsci_corp_signal_tag":
+		name = d.str(s, "short_name")
+	default:
+		name = d.str(s, "name")
+	}
+	return derived_id(resourceType, name)
+}
+
+func derived_id(resourceType string, name string) string {
+	prefix := "corp."
+	if strings.HasPrefix(resourceType, "sigsci_site_") {
+		prefix = "site."
+	}
+	return prefix + strings.ReplaceAll(strings.ToLower(name), " ", "-")
+}
+
+// value evaluates the attribute name of s, returning a null value when it
+// is not set
+func (d *resourceDecoder) value(s hclScope, name string, ty cty.Type) cty.Value {
+	attr, ok := s.body.Attributes[name]
+	if !ok {
+		return cty.NullVal(ty)
+	}
+	value, diags := attr.Expr.Value(s.ctx)
+	d.diags = append(d.diags, diags...)
+	if diags.HasErrors() {
+		return cty.NullVal(ty)
+	}
+	if !value.IsWhollyKnown() {
+		d.diags = append(d.diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Unset variable",
+			Detail:   fmt.Sprintf("%s depends on a variable without a TF_VAR_* value", name),
+			Subject:  attr.Expr.Range().Ptr(),
+		})
+		return cty.NullVal(ty)
+	}
+	converted, err := convert.Convert(value, ty)
+	if err != nil {
+		d.diags = append(d.diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Incorrect attribute value type",
+			Detail:   fmt.Sprintf("%s: %v", name, err),
+			Subject:  attr.Expr.Range().Ptr(),
+		})
+		return cty.NullVal(ty)
+	}
+	return converted
+}
+
+func (d *resourceDecoder) str(s hclScope, name string) string {
+	var result string
+	if value := d.value(s, name, cty.String); !value.IsNull() {
+		result = value.AsString()
+	}
+	return result
+}
+
+func (d *resourceDecoder) integer(s hclScope, name string) int {
+	var result int
+	if value := d.value(s, name, cty.Number); !value.IsNull() {
+		if err := gocty.FromCtyValue(value, &result); err != nil {
+			d.diags = append(d.diags, &hcl.Diagnostic{Severity: hcl.DiagError, Summary: "Invalid number", Detail: fmt.Sprintf("%s: %v", name, err)})
+		}
+	}
+	return result
+}
+
+func (d *resourceDecoder) boolean(s hclScope, name string) bool {
+	value := d.value(s, name, cty.Bool)
+	return !value.IsNull() && value.True()
+}
+
+func (d *resourceDecoder) strings(s hclScope, name string) []string {
+	var result []string
+	value := d.value(s, name, cty.List(cty.String))
+	if value.IsNull() {
+		return result
+	}
+	for _, element := range value.AsValueSlice() {
+		if !element.IsNull() {
+			result = append(result, element.AsString())
+		}
+	}
+	return result
+}
+
+// blocks returns the nested blocks of blockType in s, expanding dynamic
+// blocks
+func (d *resourceDecoder) blocks(s hclScope, blockType string) []hclScope {
+	var scopes []hclScope
+	for _, block := range s.body.Blocks {
+		switch {
+		case block.Type == blockType:
+			scopes = append(scopes, hclScope{body: block.Body, ctx: s.ctx})
+		case block.Type == "dynamic" && len(block.Labels) == 1 && block.Labels[0] == blockType:
+			scopes = append(scopes, d.expand_dynamic(s, block)...)
+		}
+	}
+	return scopes
+}
+
+func (d *resourceDecoder) expand_dynamic(s hclScope, block *hclsyntax.Block) []hclScope {
+	iterator := block.Labels[0]
+	if attr, ok := block.Body.Attributes["iterator"]; ok {
+		iterator = hcl.ExprAsKeyword(attr.Expr)
+	}
+	var content *hclsyntax.Body
+	for _, child := range block.Body.Blocks {
+		if child.Type == "content" {
+			content = child.Body
+		}
+	}
+	forEachAttr, ok := block.Body.Attributes["for_each"]
+	if !ok || content == nil {
+		d.diags = append(d.diags, &hcl.Diagnostic{Severity: hcl.DiagError, Summary: "Invalid dynamic block", Detail: fmt.Sprintf("dynamic %q needs for_each and content", block.Labels[0]), Subject: block.DefRange().Ptr()})
+		return nil
+	}
+	forEach, diags := forEachAttr.Expr.Value(s.ctx)
+	d.diags = append(d.diags, diags...)
+	if diags.HasErrors() {
+		return nil
+	}
+
+	var scopes []hclScope
+	for _, element := range sortedElements(forEach) {
+		child := s.ctx.NewChild()
+		child.Variables = map[string]cty.Value{
+			iterator: cty.ObjectVal(map[string]cty.Value{"key": element.key, "value": element.value}),
+		}
+		scopes = append(scopes, hclScope{body: content, ctx: child})
+	}
+	return scopes
+}
+
+func (d *resourceDecoder) conditions(s hclScope) []sigsci.Condition {
+	var conditions []sigsci.Condition
+	for _, block := range d.blocks(s, "conditions") {
+		conditions = append(conditions, sigsci.Condition{
+			Type:          d.str(block, "type"),
+			GroupOperator: d.str(block, "group_operator"),
+			Field:         d.str(block, "field"),
+			Operator:      d.str(block, "operator"),
+			Value:         d.str(block, "value"),
+			Conditions:    d.conditions(block),
+		})
+	}
+	return conditions
+}
+
+func (d *resourceDecoder) actions(s hclScope) []sigsci.Action {
+	var actions []sigsci.Action
+	for _, block := range d.blocks(s, "actions") {
+		actions = append(actions, sigsci.Action{
+			Type:             d.str(block, "type"),
+			Signal:           d.str(block, "signal"),
+			ResponseCode:     d.integer(block, "response_code"),
+			RedirectURL:      d.str(block, "redirect_url"),
+			AllowInteractive: d.boolean(block, "allow_interactive"),
+		})
+	}
+	return actions
+}
+
+func (d *resourceDecoder) rate_limit(s hclScope) *sigsci.RateLimit {
+	blocks := d.blocks(s, "rate_limit")
+	if len(blocks) == 0 {
+		return nil
+	}
+	block := blocks[0]
+	rateLimit := &sigsci.RateLimit{
+		Threshold: d.integer(block, "threshold"),
+		Interval:  d.integer(block, "interval"),
+		Duration:  d.integer(block, "duration"),
+	}
+	for _, identifier := range d.blocks(block, "client_identifiers") {
+		rateLimit.ClientIdentifiers = append(rateLimit.ClientIdentifiers, sigsci.ClientIdentifier{
+			Type: d.str(identifier, "type"),
+			Key:  d.str(identifier, "key"),
+			Name: d.str(identifier, "name"),
+		})
+	}
+	return rateLimit
+}
+
+// decode converts a resource of resourceType to its API body, returning
+// its site as well
+func (d *resourceDecoder) decode(resourceType string, s hclScope) (string, interface{}) {
+	site := d.str(s, "site_short_name")
+	switch resourceType {
+	case "sigsci_corp_rule":
+		return site, sigsci.CreateCorpRuleBody{
+			SiteNames:      d.strings(s, "site_short_names"),
+			Type:           d.str(s, "type"),
+			CorpScope:      d.str(s, "corp_scope"),
+			Enabled:        d.boolean(s, "enabled"),
+			GroupOperator:  d.str(s, "group_operator"),
+			Signal:         d.str(s, "signal"),
+			Reason:         d.str(s, "reason"),
+			Expiration:     d.str(s, "expiration"),
+			Conditions:     d.conditions(s),
+			Actions:        d.actions(s),
+			RequestLogging: d.str(s, "requestlogging"),
+		}
+	case "sigsci_site_rule":
+		return site, sigsci.CreateSiteRuleBody{
+			Type:           d.str(s, "type"),
+			GroupOperator:  d.str(s, "group_operator"),
+			Enabled:        d.boolean(s, "enabled"),
+			Reason:         d.str(s, "reason"),
+			Signal:         d.str(s, "signal"),
+			Expiration:     d.str(s, "expiration"),
+			Conditions:     d.conditions(s),
+			Actions:        d.actions(s),
+			RateLimit:      d.rate_limit(s),
+			RequestLogging: d.str(s, "requestlogging"),
+		}
+	case "sigsci_corp_list", "sigsci_site_list":
+		return site, sigsci.CreateListBody{
+			Name:        d.str(s, "name"),
+			Type:        d.str(s, "type"),
+			Description: d.str(s, "description"),
+			Entries:     d.strings(s, "entries"),
+		}
+	case "sigsci_corp_signal_tag":
+		return site, sigsci.CreateSignalTagBody{
+			ShortName:   d.str(s, "short_name"),
+			Description: d.str(s, "description"),
+		}
+	case "sigsci_site_signal_tag":
+		return site, sigsci.CreateSignalTagBody{
+			ShortName:   d.str(s, "name"),
+			Description: d.str(s, "description"),
+		}
+	case "sigsci_site_alert", "sigsci_site_agent_alert":
+		return site, sigsci.CustomAlertBody{
+			TagName:              d.str(s, "tag_name"),
+			LongName:             d.str(s, "long_name"),
+			Interval:             d.integer(s, "interval"),
+			Threshold:            d.integer(s, "threshold"),
+			Enabled:              d.boolean(s, "enabled"),
+			Action:               d.str(s, "action"),
+			SkipNotifications:    d.boolean(s, "skip_notifications"),
+			BlockDurationSeconds: d.integer(s, "block_duration_seconds"),
+		}
+	case "sigsci_site_integration":
+		return site, sigsci.IntegrationBody{
+			URL:    d.str(s, "url"),
+			Type:   d.str(s, "type"),
+			Events: d.strings(s, "events"),
+		}
+	case "sigsci_site_header_link":
+		return site, sigsci.HeaderLinkBody{
+			Type:     d.str(s, "type"),
+			Name:     d.str(s, "name"),
+			LinkName: d.str(s, "link_name"),
+			Link:     d.str(s, "link"),
+		}
+	case "sigsci_site":
+		body := sigsci.CreateSiteBody{
+			Name:                 d.str(s, "short_name"),
+			DisplayName:          d.str(s, "display_name"),
+			AgentLevel:           d.str(s, "agent_level"),
+			AgentAnonMode:        d.str(s, "agent_anon_mode"),
+			BlockHTTPCode:        d.integer(s, "block_http_code"),
+			BlockRedirectURL:     d.str(s, "block_redirect_url"),
+			BlockDurationSeconds: d.integer(s, "block_duration_seconds"),
+			ClientIPRules:        sigsci.ClientIPRules{},
+		}
+		for _, block := range d.blocks(s, "client_ip_rules") {
+			body.ClientIPRules = append(body.ClientIPRules, struct {
+				Header string `json:"header"`
+			}{Header: d.str(block, "header")})
+		}
+		return "", body
+	case "sigsci_site_templated_rule":
+		rule := ResponseSiteLegacyTemplatedRuleBody{Name: d.str(s, "name")}
+		for _, block := range d.blocks(s, "detections") {
+			detection := Detection{Name: rule.Name, Enabled: d.boolean(block, "enabled")}
+			for _, field := range d.blocks(block, "fields") {
+				detection.Fields = append(detection.Fields, Field{Name: d.str(field, "name"), Value: d.str(field, "value")})
+			}
+			rule.Detections = append(rule.Detections, detection)
+		}
+		for _, block := range d.blocks(s, "alerts") {
+			rule.Alerts = append(rule.Alerts, sigsci.CustomAlert{
+				LongName:          d.str(block, "long_name"),
+				Interval:          d.integer(block, "interval"),
+				Threshold:         d.integer(block, "threshold"),
+				SkipNotifications: d.boolean(block, "skip_notifications"),
+				Enabled:           d.boolean(block, "enabled"),
+				Action:            d.str(block, "action"),
+			})
+		}
+		return site, rule
+	}
+	return site, nil
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Output modes for the discovered imports
+const (
+	outputTerraform    = "terraform"
+	outputImportScript = "import-script"
+	outputCdktfTS      = "cdktf-typescript"
+	outputCdktfGo      = "cdktf-go"
+	outputPulumiYAML   = "pulumi-yaml"
+)
+
+func validOutput(output string) error {
+	switch output {
+	case outputTerraform, outputImportScript, outputCdktfTS, outputCdktfGo, outputPulumiYAML:
+		return nil
+	}
+	return fmt.Errorf("unknown output %q, expected one of %s", output, strings.Join([]string{outputTerraform, outputImportScript, outputCdktfTS, outputCdktfGo, outputPulumiYAML}, ", "))
+}
+
+// isProgramOutput reports whether output is a CDKTF or Pulumi program, which
+// configures the provider itself
+func isProgramOutput(output string) bool {
+	return output == outputCdktfTS || output == outputCdktfGo || output == outputPulumiYAML
+}
+
+// ImportTarget is one existing NGWAF object to bring under Terraform
+type ImportTarget struct {
+	ResourceType string `json:"resource_type"`
+	Name         string `json:"name"`
+	ID           string `json:"id"`
+	// Site is the short name of the owning site, empty for corp objects
+	Site string `json:"-"`
+	// Object is the API object the target was discovered from
+	Object interface{} `json:"-"`
+}
+
+// Address is the Terraform resource address of the target
+func (t ImportTarget) Address() string {
+	return t.ResourceType + "." + t.Name
+}
+
+// ImportSet collects the import targets of a run in discovery order
+type ImportSet struct {
+	Targets []ImportTarget
+}
+
+func (s *ImportSet) add(resourceType string, name string, id string, site string, object interface{}) {
+	s.Targets = append(s.Targets, ImportTarget{ResourceType: resourceType, Name: name, ID: id, Site: site, Object: object})
+}
+
+// write_import_blocks appends an import block per target to fileName.
+// providerAlias, when set, selects the aliased sigsci provider.
+func write_import_blocks(imports *ImportSet, fileName string, providerAlias string) bool {
+	file := hclwrite.NewEmptyFile()
+
+	for _, target := range imports.Targets {
+		block := file.Body().AppendNewBlock("import", nil)
+		block.Body().SetAttributeValue("id", cty.StringVal(target.ID))
+		block.Body().SetAttributeTraversal("to", hcl.Traversal{
+			hcl.TraverseRoot{Name: target.ResourceType},
+			hcl.TraverseAttr{Name: target.Name},
+		})
+		if providerAlias != "" {
+			block.Body().SetAttributeTraversal("provider", hcl.Traversal{
+				hcl.TraverseRoot{Name: "sigsci"},
+				hcl.TraverseAttr{Name: providerAlias},
+			})
+		}
+	}
+
+	return write_terraform_config_to_file(file, fileName)
+}
+
+// shellQuote quotes s for POSIX shells
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// write_import_script writes import.sh with a `terraform import` command per
+// target, for Terraform versions before 1.5 that lack import blocks, and
+// imports.json listing the same targets for other tooling
+func write_import_script(imports *ImportSet, outputDir string) error {
+	var script bytes.Buffer
+	script.WriteString("#!/bin/sh\n")
+	script.WriteString("# Imports existing NGWAF objects into the Terraform state.\n")
+	script.WriteString("# The resource blocks must already exist in the configuration.\n")
+	script.WriteString("set -e\n\n")
+	for _, target := range imports.Targets {
+		fmt.Fprintf(&script, "terraform import %s %s\n", shellQuote(target.Address()), shellQuote(target.ID))
+	}
+	if err := os.WriteFile(filepath.Join(outputDir, "import.sh"), script.Bytes(), 0755); err != nil {
+		return fmt.Errorf("error writing import.sh: %v", err)
+	}
+
+	type manifestEntry struct {
+		Address string `json:"address"`
+		ImportTarget
+	}
+	manifest := []manifestEntry{}
+	for _, target := range imports.Targets {
+		manifest = append(manifest, manifestEntry{Address: target.Address(), ImportTarget: target})
+	}
+	content, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(outputDir, "imports.json"), append(content, '\n'), 0666); err != nil {
+		return fmt.Errorf("error writing imports.json: %v", err)
+	}
+	return nil
+}
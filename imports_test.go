@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestImportScriptGolden(t *testing.T) {
+	_, sc, api := newFakeAPI(t, "testcorp")
+	outputDir := t.TempDir()
+
+	opts := RunOptions{Format: formatHCL, Output: outputImportScript}
+	if err := terraformify_corp(context.Background(), sc, api, "testcorp", outputDir, opts, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	assertGolden(t, filepath.Join(outputDir, "import.sh"), filepath.Join("testcorp", "import.sh"))
+	assertGolden(t, filepath.Join(outputDir, "imports.json"), filepath.Join("testcorp", "imports.json"))
+}
+
+func TestShellQuote(t *testing.T) {
+	for _, value := range []string{
+		"www:61b2c3d4e5f60718293a4b5c",
+		"my site:list with spaces",
+		"it's $HOME `date` \"quoted\"",
+	} {
+		out, err := exec.Command("sh", "-c", "printf '%s' "+shellQuote(value)).Output()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(out) != value {
+			t.Errorf("got %q, want %q", out, value)
+		}
+	}
+}
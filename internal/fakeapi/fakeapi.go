@@ -0,0 +1,310 @@
+// Package fakeapi is an in-memory stand-in for the NGWAF API used by the
+// end-to-end tests. It is seeded from JSON fixtures keyed by collection path,
+// e.g. "corps/testcorp/sites/www/rules", and serves the same response
+// envelopes as the real API.
+package fakeapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Fixture maps a collection path (relative to /api/v0/) to its objects
+type Fixture map[string][]json.RawMessage
+
+// LoadFixture reads a fixture file from disk
+func LoadFixture(path string) (Fixture, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading fixture: %v", err)
+	}
+	var fixture Fixture
+	if err := json.Unmarshal(content, &fixture); err != nil {
+		return nil, fmt.Errorf("error parsing fixture %s: %v", path, err)
+	}
+	return fixture, nil
+}
+
+// Request is a call received by the server
+type Request struct {
+	Method string
+	Path   string
+	Body   string
+}
+
+// Server is a fake NGWAF API backed by httptest
+type Server struct {
+	*httptest.Server
+
+	mu          sync.Mutex
+	collections map[string][]map[string]interface{}
+	failures    map[string][]int
+	requests    []Request
+	nextID      int
+}
+
+// New starts a fake API server seeded with fixture
+func New(fixture Fixture) *Server {
+	s := &Server{
+		collections: map[string][]map[string]interface{}{},
+		failures:    map[string][]int{},
+	}
+	for key, items := range fixture {
+		var objects []map[string]interface{}
+		for _, raw := range items {
+			var object map[string]interface{}
+			if err := json.Unmarshal(raw, &object); err != nil {
+				panic(fmt.Sprintf("fakeapi: invalid object in %s: %v", key, err))
+			}
+			objects = append(objects, object)
+		}
+		s.collections[strings.Trim(key, "/")] = objects
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// APIURL is the base URL to configure clients with
+func (s *Server) APIURL() string {
+	return s.URL + "/api"
+}
+
+// FailNext makes the next len(statuses) requests to path (relative to
+// /api/v0/) answer with the given status codes
+func (s *Server) FailNext(path string, statuses ...int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := strings.Trim(path, "/")
+	s.failures[key] = append(s.failures[key], statuses...)
+}
+
+// Requests returns the calls received so far
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// Collection returns the current objects of a collection
+func (s *Server) Collection(path string) []map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]map[string]interface{}(nil), s.collections[strings.Trim(path, "/")]...)
+}
+
+// Keys returns every seeded or created collection path, sorted
+func (s *Server) Keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var keys []string
+	for key := range s.collections {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v0/"), "/")
+	s.requests = append(s.requests, Request{Method: r.Method, Path: path, Body: string(body)})
+
+	if r.Header.Get("X-API-User") == "" || r.Header.Get("X-API-Token") == "" {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"message": "Unauthorized"})
+		return
+	}
+
+	if statuses := s.failures[path]; len(statuses) > 0 {
+		s.failures[path] = statuses[1:]
+		writeJSON(w, statuses[0], map[string]string{"message": http.StatusText(statuses[0])})
+		return
+	}
+
+	collection, id := s.route(path, r.Method)
+	switch {
+	case id == "" && r.Method == http.MethodGet:
+		items := s.collections[collection]
+		if items == nil {
+			items = []map[string]interface{}{}
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"totalCount": len(items), "data": items})
+	case id == "" && r.Method == http.MethodPost:
+		var object map[string]interface{}
+		if err := json.Unmarshal(body, &object); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"message": err.Error()})
+			return
+		}
+		field := idField(collection)
+		if _, ok := object[field]; !ok {
+			s.nextID++
+			object[field] = fmt.Sprintf("fake%06d", s.nextID)
+		}
+		s.collections[collection] = append(s.collections[collection], object)
+		writeJSON(w, http.StatusOK, object)
+	case id != "":
+		index := s.find(collection, id)
+		if index < 0 {
+			writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, s.collections[collection][index])
+		case http.MethodPut, http.MethodPatch:
+			var update map[string]interface{}
+			if err := json.Unmarshal(body, &update); err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"message": err.Error()})
+				return
+			}
+			object := s.collections[collection][index]
+			for key, value := range update {
+				// List updates add and delete entries
+				if changes, ok := value.(map[string]interface{}); ok && key == "entries" {
+					value = applyEntryChanges(object["entries"], changes)
+				}
+				object[key] = value
+			}
+			writeJSON(w, http.StatusOK, object)
+		case http.MethodPost:
+			// Configured templates are updated by posting their changes
+			var changes map[string][]map[string]interface{}
+			if err := json.Unmarshal(body, &changes); err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"message": err.Error()})
+				return
+			}
+			object := s.collections[collection][index]
+			for _, kind := range []string{"detections", "alerts"} {
+				singular := strings.TrimSuffix(kind, "s")
+				object[kind] = s.applyItemChanges(object[kind], changes[singular+"Adds"], changes[singular+"Updates"], changes[singular+"Deletes"])
+			}
+			writeJSON(w, http.StatusOK, object)
+		case http.MethodDelete:
+			items := s.collections[collection]
+			s.collections[collection] = append(items[:index:index], items[index+1:]...)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"message": "Method Not Allowed"})
+		}
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"message": "Method Not Allowed"})
+	}
+}
+
+// route splits path into a collection and an optional object ID
+func (s *Server) route(path string, method string) (string, string) {
+	if _, ok := s.collections[path]; ok {
+		return path, ""
+	}
+	slash := strings.LastIndex(path, "/")
+	if slash < 0 {
+		return path, ""
+	}
+	parent, id := path[:slash], path[slash+1:]
+	if _, ok := s.collections[parent]; ok && s.find(parent, id) >= 0 {
+		return parent, id
+	}
+	if method == http.MethodPost {
+		return path, ""
+	}
+	if method == http.MethodGet && isCollectionName(id) {
+		// An unseeded collection is simply empty
+		return path, ""
+	}
+	return parent, id
+}
+
+func (s *Server) find(collection string, id string) int {
+	field := idField(collection)
+	for i, object := range s.collections[collection] {
+		if value, ok := object[field].(string); ok && value == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func isCollectionName(name string) bool {
+	switch name {
+	case "rules", "lists", "tags", "sites", "alerts", "integrations", "headerLinks", "configuredtemplates":
+		return true
+	}
+	return false
+}
+
+// idField is the attribute that identifies objects in a collection
+func idField(collection string) string {
+	switch collection[strings.LastIndex(collection, "/")+1:] {
+	case "tags":
+		return "tagName"
+	case "sites", "configuredtemplates":
+		return "name"
+	}
+	return "id"
+}
+
+// applyItemChanges applies the adds, updates and deletes of a configured
+// template update to its detections or alerts, matched by ID
+func (s *Server) applyItemChanges(items interface{}, adds, updates, deletes []map[string]interface{}) []interface{} {
+	current, _ := items.([]interface{})
+	updated := []interface{}{}
+	for _, item := range current {
+		object, _ := item.(map[string]interface{})
+		deleted := false
+		for _, deletion := range deletes {
+			deleted = deleted || object["id"] == deletion["id"]
+		}
+		if deleted {
+			continue
+		}
+		for _, update := range updates {
+			if object["id"] == update["id"] {
+				for key, value := range update {
+					object[key] = value
+				}
+			}
+		}
+		updated = append(updated, object)
+	}
+	for _, add := range adds {
+		s.nextID++
+		add["id"] = fmt.Sprintf("fake%06d", s.nextID)
+		updated = append(updated, add)
+	}
+	return updated
+}
+
+// applyEntryChanges applies the additions and deletions of a list update to
+// the entries of the list
+func applyEntryChanges(entries interface{}, changes map[string]interface{}) []interface{} {
+	current, _ := entries.([]interface{})
+	deletions, _ := changes["deletions"].([]interface{})
+	updated := []interface{}{}
+	for _, entry := range current {
+		deleted := false
+		for _, deletion := range deletions {
+			deleted = deleted || entry == deletion
+		}
+		if !deleted {
+			updated = append(updated, entry)
+		}
+	}
+	additions, _ := changes["additions"].([]interface{})
+	return append(updated, additions...)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
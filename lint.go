@@ -0,0 +1,455 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	sigsci "github.com/signalsciences/go-sigsci"
+)
+
+// Lint levels, named like SARIF levels
+const (
+	lintError   = "error"
+	lintWarning = "warning"
+	lintNote    = "note"
+)
+
+// lintLevelRank orders levels for -fail-on
+var lintLevelRank = map[string]int{lintNote: 1, lintWarning: 2, lintError: 3}
+
+// lintCheck describes one lint check
+type lintCheck struct {
+	ID          string
+	Level       string
+	Description string
+}
+
+// lintChecks are the checks lint runs
+var lintChecks = []lintCheck{
+	{"missing-list", lintError, "Rule references a list that does not exist"},
+	{"missing-signal", lintError, "Rule references a signal that does not exist"},
+	{"duplicate-rule", lintWarning, "Rule duplicates an earlier rule"},
+	{"shadowed-rule", lintWarning, "Rule never runs, an earlier rule with the same conditions blocks or allows the request"},
+	{"old-disabled-rule", lintNote, "Rule has been disabled for long"},
+	{"expired-rule", lintWarning, "Rule expired but is still present"},
+	{"empty-list", lintWarning, "List has no entries"},
+	{"rate-limit-without-client-identifier", lintError, "Rate limit rule counts no client identifier"},
+	{"block-without-reason", lintWarning, "Block rule has no reason"},
+}
+
+// lintFinding is a problem lint found in an object
+type lintFinding struct {
+	Check string `json:"check"`
+	Level string `json:"level"`
+	// Address is the Terraform address of the object, empty for objects
+	// the tool does not import
+	Address string `json:"address,omitempty"`
+	ID      string `json:"id"`
+	Message string `json:"message"`
+	// File and Line locate the resource in the configuration, when lint
+	// was given the files
+	File string `json:"file,omitempty"`
+	Line int    `json:"line,omitempty"`
+}
+
+// lintOptions tune the checks
+type lintOptions struct {
+	Now             time.Time
+	MaxDisabledDays int
+}
+
+// lintRule is a corp or site rule with what the checks need to know
+type lintRule struct {
+	ID string
+	// Site is the site of a site rule, empty for corp rules
+	Site    string
+	Body    sigsci.CreateSiteRuleBody
+	Updated time.Time
+	// Scope is the corp scope and sites of a corp rule
+	Scope string
+}
+
+// lint_snapshot runs every check over snapshot. Findings come in the order
+// of the objects, corp first.
+func lint_snapshot(snapshot CorpSnapshot, opts lintOptions) []lintFinding {
+	addresses := map[string]string{}
+	for _, target := range import_set_from_snapshot(snapshot, nil).Targets {
+		addresses[target.ID] = target.Address()
+	}
+	var findings []lintFinding
+	report := func(check string, id string, format string, args ...interface{}) {
+		finding := lintFinding{Check: check, Address: addresses[id], ID: id, Message: fmt.Sprintf(format, args...)}
+		for _, c := range lintChecks {
+			if c.ID == check {
+				finding.Level = c.Level
+			}
+		}
+		findings = append(findings, finding)
+	}
+
+	corpLists := map[string]bool{}
+	for _, list := range snapshot.Lists.Data {
+		corpLists[list.ID] = true
+		if len(list.Entries) == 0 {
+			report("empty-list", list.ID, "list %q has no entries", list.Name)
+		}
+	}
+	corpSignals := map[string]bool{}
+	for _, signal := range snapshot.Signals.Data {
+		corpSignals[signal.TagName] = true
+	}
+
+	var corpRules []lintRule
+	for _, rule := range snapshot.Rules.Data {
+		corpRules = append(corpRules, lintRule{
+			ID:      rule.ID,
+			Body:    corp_rule_as_site_rule(rule.CreateCorpRuleBody),
+			Updated: rule.Updated,
+			Scope:   rule.CorpScope + ":" + strings.Join(rule.SiteNames, ","),
+		})
+	}
+	for _, rule := range corpRules {
+		lint_rule(rule, corpLists, corpSignals, opts, report)
+	}
+	lint_rule_pairs(corpRules, report)
+
+	for _, site := range snapshot.Sites {
+		siteLists := map[string]bool{}
+		for id := range corpLists {
+			siteLists[id] = true
+		}
+		for _, list := range site.Lists.Data {
+			siteLists[list.ID] = true
+			if len(list.Entries) == 0 {
+				report("empty-list", site.Site.Name+":"+list.ID, "list %q of site %s has no entries", list.Name, site.Site.Name)
+			}
+		}
+		siteSignals := map[string]bool{}
+		for id := range corpSignals {
+			siteSignals[id] = true
+		}
+		for _, signal := range site.Signals.Data {
+			siteSignals[signal.TagName] = true
+		}
+
+		var siteRules []lintRule
+		for _, rule := range site.Rules.Data {
+			siteRules = append(siteRules, lintRule{ID: site.Site.Name + ":" + rule.ID, Site: site.Site.Name, Body: rule.CreateSiteRuleBody, Updated: rule.Updated})
+		}
+		for _, rule := range siteRules {
+			lint_rule(rule, siteLists, siteSignals, opts, report)
+		}
+
+		// Corp rules applying to the site run before its own rules
+		var effective []lintRule
+		for i, rule := range snapshot.Rules.Data {
+			if rule.CorpScope == "global" || slices.Contains(rule.SiteNames, site.Site.Name) {
+				effective = append(effective, corpRules[i])
+			}
+		}
+		lint_rule_pairs(append(effective, siteRules...), func(check string, id string, format string, args ...interface{}) {
+			// Pairs of corp rules were checked once for the corp
+			if strings.Contains(id, ":") {
+				report(check, id, format, args...)
+			}
+		})
+	}
+	return findings
+}
+
+type lintReport func(check string, id string, format string, args ...interface{})
+
+// corp_rule_as_site_rule drops the scope of a corp rule, so corp and site
+// rules compare
+func corp_rule_as_site_rule(rule sigsci.CreateCorpRuleBody) sigsci.CreateSiteRuleBody {
+	return sigsci.CreateSiteRuleBody{
+		Type:          rule.Type,
+		GroupOperator: rule.GroupOperator,
+		Enabled:       rule.Enabled,
+		Reason:        rule.Reason,
+		Signal:        rule.Signal,
+		Expiration:    rule.Expiration,
+		Conditions:    rule.Conditions,
+		Actions:       rule.Actions,
+	}
+}
+
+// lint_rule runs the checks of a single rule. lists and signals are the
+// IDs of the lists and signals in its scope.
+func lint_rule(rule lintRule, lists map[string]bool, signals map[string]bool, opts lintOptions, report lintReport) {
+	body := rule.Body
+	describe := "rule " + rule.ID
+	if body.Reason != "" {
+		describe = fmt.Sprintf("rule %s (%s)", rule.ID, body.Reason)
+	}
+
+	checkSignal := func(signal string) {
+		// System signals have no prefix
+		if (strings.HasPrefix(signal, "corp.") || strings.HasPrefix(signal, "site.")) && !signals[signal] {
+			report("missing-signal", rule.ID, "%s references signal %s, which does not exist", describe, signal)
+		}
+	}
+	var checkConditions func(conditions []sigsci.Condition)
+	checkConditions = func(conditions []sigsci.Condition) {
+		for _, condition := range conditions {
+			checkConditions(condition.Conditions)
+			switch {
+			case condition.Operator == "inList" || condition.Operator == "notInList":
+				if !lists[condition.Value] {
+					report("missing-list", rule.ID, "%s references list %s, which does not exist", describe, condition.Value)
+				}
+			case isSignalField(condition.Field):
+				checkSignal(condition.Value)
+			}
+		}
+	}
+	checkConditions(body.Conditions)
+	checkSignal(body.Signal)
+	blocks := false
+	for _, action := range body.Actions {
+		checkSignal(action.Signal)
+		blocks = blocks || action.Type == "block"
+	}
+
+	if !body.Enabled && opts.MaxDisabledDays > 0 && !rule.Updated.IsZero() {
+		if days := int(opts.Now.Sub(rule.Updated).Hours() / 24); days > opts.MaxDisabledDays {
+			report("old-disabled-rule", rule.ID, "%s is disabled and unchanged for %d days", describe, days)
+		}
+	}
+	if expiration, err := time.Parse(time.RFC3339, body.Expiration); err == nil && expiration.Before(opts.Now) {
+		report("expired-rule", rule.ID, "%s expired on %s", describe, expiration.Format("2006-01-02"))
+	}
+	if body.Type == "rateLimit" && (body.RateLimit == nil || len(body.RateLimit.ClientIdentifiers) == 0) {
+		report("rate-limit-without-client-identifier", rule.ID, "%s counts no client identifier", describe)
+	}
+	if blocks && strings.TrimSpace(body.Reason) == "" {
+		report("block-without-reason", rule.ID, "%s blocks requests without a reason", describe)
+	}
+}
+
+// lint_rule_pairs reports enabled rules duplicating an earlier enabled
+// rule, and rules that block or allow shadowed by an earlier rule with the
+// same conditions that blocks or allows
+func lint_rule_pairs(rules []lintRule, report lintReport) {
+	for j, later := range rules {
+		if !later.Body.Enabled {
+			continue
+		}
+		for _, earlier := range rules[:j] {
+			if !earlier.Body.Enabled || (earlier.Site == "" && later.Site == "" && earlier.Scope != later.Scope) {
+				continue
+			}
+			if !same_conditions(earlier.Body, later.Body) {
+				continue
+			}
+			if same_rule(earlier.Body, later.Body) {
+				report("duplicate-rule", later.ID, "rule %s duplicates rule %s", later.ID, earlier.ID)
+				break
+			}
+			if terminal_action(earlier.Body) && terminal_action(later.Body) {
+				report("shadowed-rule", later.ID, "rule %s never runs, rule %s with the same conditions runs first", later.ID, earlier.ID)
+				break
+			}
+		}
+	}
+}
+
+func same_conditions(a sigsci.CreateSiteRuleBody, b sigsci.CreateSiteRuleBody) bool {
+	return a.Type == b.Type && a.GroupOperator == b.GroupOperator &&
+		reflect.DeepEqual(normalize_conditions(a.Conditions), normalize_conditions(b.Conditions))
+}
+
+// same_rule compares what a rule does, not its reason, expiration or
+// logging
+func same_rule(a sigsci.CreateSiteRuleBody, b sigsci.CreateSiteRuleBody) bool {
+	return a.Signal == b.Signal && reflect.DeepEqual(a.Actions, b.Actions) && reflect.DeepEqual(a.RateLimit, b.RateLimit)
+}
+
+func terminal_action(rule sigsci.CreateSiteRuleBody) bool {
+	for _, action := range rule.Actions {
+		if action.Type == "block" || action.Type == "allow" {
+			return true
+		}
+	}
+	return false
+}
+
+// normalize_conditions sorts conditions, whose order does not matter
+func normalize_conditions(conditions []sigsci.Condition) []string {
+	var normalized []string
+	for _, condition := range conditions {
+		nested := normalize_conditions(condition.Conditions)
+		condition.Conditions = nil
+		content, _ := json.Marshal(condition)
+		normalized = append(normalized, string(content)+strings.Join(nested, ""))
+	}
+	sort.Strings(normalized)
+	return normalized
+}
+
+// locate_findings sets the file and line of findings whose resource is
+// declared in files
+func locate_findings(findings []lintFinding, files []configFile) error {
+	locations := map[string]hcl.Range{}
+	for _, file := range files {
+		syntax, diags := hclsyntax.ParseConfig(file.Src, file.Path, hcl.InitialPos)
+		if diags.HasErrors() {
+			return fmt.Errorf("error parsing %s: %v", file.Path, diags)
+		}
+		for _, block := range syntax.Body.(*hclsyntax.Body).Blocks {
+			if block.Type == "resource" && len(block.Labels) == 2 {
+				locations[block.Labels[0]+"."+block.Labels[1]] = block.DefRange()
+			}
+		}
+	}
+	for i := range findings {
+		if location, ok := locations[findings[i].Address]; ok {
+			findings[i].File = location.Filename
+			findings[i].Line = location.Start.Line
+		}
+	}
+	return nil
+}
+
+// write_lint_text writes a line per finding
+func write_lint_text(w io.Writer, findings []lintFinding) {
+	for _, finding := range findings {
+		location := finding.Address
+		if location == "" {
+			location = finding.ID
+		}
+		if finding.File != "" {
+			location = fmt.Sprintf("%s:%d: %s", finding.File, finding.Line, finding.Address)
+		}
+		fmt.Fprintf(w, "%s: %s: %s [%s]\n", location, finding.Level, finding.Message, finding.Check)
+	}
+}
+
+// write_lint_sarif writes findings as a SARIF 2.1.0 log, for code scanning
+// annotations
+func write_lint_sarif(w io.Writer, findings []lintFinding) error {
+	type object = map[string]interface{}
+	var rules []object
+	for _, check := range lintChecks {
+		rules = append(rules, object{
+			"id":                   check.ID,
+			"shortDescription":     object{"text": check.Description},
+			"defaultConfiguration": object{"level": check.Level},
+		})
+	}
+	results := []object{}
+	for _, finding := range findings {
+		logical := object{"fullyQualifiedName": finding.Address, "kind": "resource"}
+		if finding.Address == "" {
+			logical = object{"fullyQualifiedName": finding.ID, "kind": "object"}
+		}
+		location := object{"logicalLocations": []object{logical}}
+		if finding.File != "" {
+			location["physicalLocation"] = object{
+				"artifactLocation": object{"uri": finding.File},
+				"region":           object{"startLine": finding.Line},
+			}
+		}
+		results = append(results, object{
+			"ruleId":    finding.Check,
+			"level":     finding.Level,
+			"message":   object{"text": finding.Message},
+			"locations": []object{location},
+		})
+	}
+	log := object{
+		"$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+		"version": "2.1.0",
+		"runs": []object{{
+			"tool":    object{"driver": object{"name": "ngwaf-terraformify", "rules": rules}},
+			"results": results,
+		}},
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(log)
+}
+
+func run_lint(ctx context.Context, sc sigsci.Client, api *APIClient, args []string) error {
+	flags := flag.NewFlagSet("lint", flag.ExitOnError)
+	corp := flags.String("corp", os.Getenv("TF_VAR_NGWAF_CORP"), "corp to lint")
+	format := flags.String("format", "text", "output format: text, json or sarif")
+	out := flags.String("out", "", "file to write the findings to, defaults to stdout")
+	maxDisabledDays := flags.Int("max-disabled-days", 90, "report rules disabled and unchanged for longer, 0 to disable")
+	failOn := flags.String("fail-on", lintError, "fail on findings of this level or above: error, warning, note or never")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: lint [-corp corp] [-format text|json|sarif] [-out file] [file.tf ...]")
+		fmt.Fprintln(flags.Output(), "Files, when given, locate the findings in the configuration.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if *format != "text" && *format != "json" && *format != "sarif" {
+		return fmt.Errorf("unknown -format %q, expected text, json or sarif", *format)
+	}
+	if _, ok := lintLevelRank[*failOn]; !ok && *failOn != "never" {
+		return fmt.Errorf("unknown -fail-on %q, expected error, warning, note or never", *failOn)
+	}
+
+	snapshot, err := fetch_corp_snapshot(ctx, sc, api, *corp)
+	if err != nil {
+		return err
+	}
+	findings := lint_snapshot(snapshot, lintOptions{Now: time.Now(), MaxDisabledDays: *maxDisabledDays})
+	if flags.NArg() > 0 {
+		files, err := read_config_files(flags.Args())
+		if err != nil {
+			return err
+		}
+		if err := locate_findings(findings, files); err != nil {
+			return err
+		}
+	}
+
+	w := io.Writer(os.Stdout)
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			return fmt.Errorf("error creating %s: %v", *out, err)
+		}
+		defer file.Close()
+		w = file
+	}
+	switch *format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if findings == nil {
+			findings = []lintFinding{}
+		}
+		err = encoder.Encode(findings)
+	case "sarif":
+		err = write_lint_sarif(w, findings)
+	default:
+		write_lint_text(w, findings)
+	}
+	if err != nil {
+		return err
+	}
+
+	failing := 0
+	for _, finding := range findings {
+		if lintLevelRank[finding.Level] >= lintLevelRank[*failOn] && *failOn != "never" {
+			failing++
+		}
+	}
+	if failing > 0 {
+		return fmt.Errorf("lint found %d problems at level %s or above", failing, *failOn)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	sigsci "github.com/signalsciences/go-sigsci"
+)
+
+var lintNow = time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+func TestLintFixture(t *testing.T) {
+	_, sc, api := newFakeAPI(t, "testcorp")
+	snapshot, err := fetch_corp_snapshot(context.Background(), sc, api, "testcorp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// An emptied list gives a finding on a resource the rendering declares
+	snapshot.Lists.Data[0].Entries = nil
+	findings := lint_snapshot(snapshot, lintOptions{Now: lintNow, MaxDisabledDays: 90})
+
+	rendered, err := render_verify_config(import_set_from_snapshot(snapshot, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := locate_findings(findings, []configFile{rendered}); err != nil {
+		t.Fatal(err)
+	}
+
+	outputDir := t.TempDir()
+	var text bytes.Buffer
+	write_lint_text(&text, findings)
+	var sarif bytes.Buffer
+	if err := write_lint_sarif(&sarif, findings); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string][]byte{"lint.txt": text.Bytes(), "lint.sarif": sarif.Bytes()} {
+		path := filepath.Join(outputDir, name)
+		if err := os.WriteFile(path, content, 0644); err != nil {
+			t.Fatal(err)
+		}
+		assertGolden(t, path, filepath.Join("testcorp", "lint", name))
+	}
+}
+
+func TestLintChecks(t *testing.T) {
+	block := []sigsci.Action{{Type: "block"}}
+	fromBadIPs := []sigsci.Condition{{Type: "single", Field: "ip", Operator: "inList", Value: "corp.bad-ips"}}
+	snapshot := CorpSnapshot{
+		Corp: "testcorp",
+		Rules: sigsci.ResponseCorpRuleBodyList{Data: []sigsci.ResponseCorpRuleBody{
+			{ID: "c1", CreateCorpRuleBody: sigsci.CreateCorpRuleBody{Type: "request", CorpScope: "global", Enabled: true, Reason: "Bad IPs", Conditions: fromBadIPs, Actions: block}},
+			{ID: "c2", CreateCorpRuleBody: sigsci.CreateCorpRuleBody{Type: "request", CorpScope: "global", Enabled: true, Reason: "Bad IPs again", Conditions: fromBadIPs, Actions: block}},
+		}},
+		Lists: sigsci.ResponseListBodyList{Data: []sigsci.ResponseListBody{
+			{ID: "corp.bad-ips", CreateListBody: sigsci.CreateListBody{Name: "Bad IPs", Type: "ip", Entries: []string{"192.0.2.1"}}},
+			{ID: "corp.empty", CreateListBody: sigsci.CreateListBody{Name: "Empty", Type: "ip"}},
+		}},
+		Sites: []SiteSnapshot{{
+			Site: sigsci.Site{Name: "www"},
+			Rules: sigsci.ResponseSiteRuleBodyList{Data: []sigsci.ResponseSiteRuleBody{
+				{ID: "s1", CreateSiteRuleBody: sigsci.CreateSiteRuleBody{Type: "request", Enabled: true, Reason: "Allow bad IPs", Conditions: fromBadIPs, Actions: []sigsci.Action{{Type: "allow"}}}},
+				{ID: "s2", CreateSiteRuleBody: sigsci.CreateSiteRuleBody{Type: "request", Enabled: true, Conditions: []sigsci.Condition{{Type: "single", Field: "ip", Operator: "inList", Value: "site.gone"}}, Actions: block}},
+				{ID: "s3", CreateSiteRuleBody: sigsci.CreateSiteRuleBody{Type: "request", Enabled: true, Reason: "Tag", Conditions: []sigsci.Condition{{Type: "single", Field: "path", Operator: "equals", Value: "/"}}, Actions: []sigsci.Action{{Type: "addSignal", Signal: "site.gone"}}}},
+				{ID: "s4", Updated: lintNow.AddDate(0, 0, -100), CreateSiteRuleBody: sigsci.CreateSiteRuleBody{Type: "request", Reason: "Old", Actions: []sigsci.Action{{Type: "logRequest"}}}},
+				{ID: "s5", CreateSiteRuleBody: sigsci.CreateSiteRuleBody{Type: "request", Enabled: true, Reason: "Expired", Expiration: "2024-01-01T00:00:00Z", Actions: []sigsci.Action{{Type: "logRequest"}}}},
+				{ID: "s6", CreateSiteRuleBody: sigsci.CreateSiteRuleBody{Type: "rateLimit", Enabled: true, Reason: "Rate", RateLimit: &sigsci.RateLimit{Threshold: 10}}},
+				{ID: "s7", CreateSiteRuleBody: sigsci.CreateSiteRuleBody{Type: "request", Enabled: true, Reason: "Tagged", Conditions: []sigsci.Condition{{Type: "single", Field: "signal", Operator: "exists", Value: "site.deleted"}}, Actions: []sigsci.Action{{Type: "logRequest"}}}},
+			}},
+		}},
+	}
+
+	type found struct{ Check, ID string }
+	var got []found
+	for _, finding := range lint_snapshot(snapshot, lintOptions{Now: lintNow, MaxDisabledDays: 90}) {
+		got = append(got, found{finding.Check, finding.ID})
+	}
+	want := []found{
+		{"empty-list", "corp.empty"},
+		{"duplicate-rule", "c2"},
+		{"missing-list", "www:s2"},
+		{"block-without-reason", "www:s2"},
+		{"missing-signal", "www:s3"},
+		{"old-disabled-rule", "www:s4"},
+		{"expired-rule", "www:s5"},
+		{"rate-limit-without-client-identifier", "www:s6"},
+		{"missing-signal", "www:s7"},
+		{"shadowed-rule", "www:s1"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("findings are\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestLintJSONAndSARIF(t *testing.T) {
+	findings := []lintFinding{{Check: "empty-list", Level: lintWarning, Address: "sigsci_corp_list.x", ID: "corp.x", Message: "list \"x\" has no entries"}}
+	var sarif bytes.Buffer
+	if err := write_lint_sarif(&sarif, findings); err != nil {
+		t.Fatal(err)
+	}
+	var log struct {
+		Version string
+		Runs    []struct {
+			Results []struct {
+				RuleID string `json:"ruleId"`
+				Level  string
+			}
+		}
+	}
+	if err := json.Unmarshal(sarif.Bytes(), &log); err != nil {
+		t.Fatal(err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 || len(log.Runs[0].Results) != 1 || log.Runs[0].Results[0].RuleID != "empty-list" || log.Runs[0].Results[0].Level != lintWarning {
+		t.Errorf("unexpected SARIF %s", sarif.String())
+	}
+}
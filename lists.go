@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Formats of the data files list entries can be externalized to
+const (
+	listFilesTxt  = "txt"
+	listFilesJSON = "json"
+)
+
+// listFilesDir is the directory, relative to the module, holding the data
+// files of externalized lists
+const listFilesDir = "lists"
+
+func isListResource(resourceType string) bool {
+	return resourceType == "sigsci_corp_list" || resourceType == "sigsci_site_list"
+}
+
+func validListFiles(format string) error {
+	switch format {
+	case "", listFilesTxt, listFilesJSON:
+		return nil
+	}
+	return fmt.Errorf("unknown list file format %q, expected %s or %s", format, listFilesTxt, listFilesJSON)
+}
+
+// uniqueSortedEntries returns entries sorted with duplicates removed, so the
+// data files only change when the list does
+func uniqueSortedEntries(entries []string) []string {
+	sorted := append([]string{}, entries...)
+	sort.Strings(sorted)
+	var unique []string
+	for i, entry := range sorted {
+		if i == 0 || entry != sorted[i-1] {
+			unique = append(unique, entry)
+		}
+	}
+	return unique
+}
+
+// externalize_list_entries writes the entries of the list configuration
+// config to lists/<name>.<format> under moduleDir and replaces them with an
+// expression reading that file. Empty lists stay inline, as splitting an
+// empty file yields one empty entry.
+func externalize_list_entries(config *tfBlock, moduleDir string, name string, format string) error {
+	for i, attr := range config.Attrs {
+		entries, ok := attr.Value.([]string)
+		if attr.Name != "entries" || !ok || len(entries) == 0 {
+			continue
+		}
+		entries = uniqueSortedEntries(entries)
+
+		var content []byte
+		switch format {
+		case listFilesTxt:
+			content = []byte(strings.Join(entries, "\n") + "\n")
+		case listFilesJSON:
+			encoded, err := json.MarshalIndent(entries, "", "  ")
+			if err != nil {
+				return err
+			}
+			content = append(encoded, '\n')
+		}
+
+		relPath := listFilesDir + "/" + name + "." + format
+		if err := os.MkdirAll(filepath.Join(moduleDir, listFilesDir), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(moduleDir, filepath.FromSlash(relPath)), content, 0666); err != nil {
+			return fmt.Errorf("error writing %s: %v", relPath, err)
+		}
+
+		read := hclwrite.TokensForFunctionCall("file", module_path_tokens(relPath))
+		if format == listFilesJSON {
+			config.Attrs[i].Value = hclwrite.TokensForFunctionCall("jsondecode", read)
+		} else {
+			config.Attrs[i].Value = hclwrite.TokensForFunctionCall("split",
+				hclwrite.TokensForValue(cty.StringVal("\n")),
+				hclwrite.TokensForFunctionCall("trimspace", read))
+		}
+	}
+	return nil
+}
+
+// module_path_tokens renders "${path.module}/<relPath>"
+func module_path_tokens(relPath string) hclwrite.Tokens {
+	// The quoted literal with its escaping, without the quotes
+	literal := hclwrite.TokensForValue(cty.StringVal("/" + relPath))
+	tokens := hclwrite.Tokens{literal[0], {Type: hclsyntax.TokenTemplateInterp, Bytes: []byte("${")}}
+	tokens = append(tokens, hclwrite.TokensForTraversal(hcl.Traversal{
+		hcl.TraverseRoot{Name: "path"},
+		hcl.TraverseAttr{Name: "module"},
+	})...)
+	tokens = append(tokens, &hclwrite.Token{Type: hclsyntax.TokenTemplateSeqEnd, Bytes: []byte("}")})
+	return append(tokens, literal[1:]...)
+}
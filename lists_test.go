@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	sigsci "github.com/signalsciences/go-sigsci"
+)
+
+func TestExternalizeListEntries(t *testing.T) {
+	list := sigsci.CreateListBody{
+		Name:    "Blocked",
+		Type:    "ip",
+		Entries: []string{"198.51.100.2", "192.0.2.1", "198.51.100.2", "10.0.0.1"},
+	}
+
+	for _, tc := range []struct {
+		format  string
+		content string
+		expr    string
+	}{
+		{listFilesTxt, "10.0.0.1\n192.0.2.1\n198.51.100.2\n", `split("\n", trimspace(file("${path.module}/lists/blocked.txt")))`},
+		{listFilesJSON, "[\n  \"10.0.0.1\",\n  \"192.0.2.1\",\n  \"198.51.100.2\"\n]\n", `jsondecode(file("${path.module}/lists/blocked.json"))`},
+	} {
+		t.Run(tc.format, func(t *testing.T) {
+			dir := t.TempDir()
+			config := list_config("", list)
+			if err := externalize_list_entries(config, dir, "blocked", tc.format); err != nil {
+				t.Fatal(err)
+			}
+
+			content, err := os.ReadFile(filepath.Join(dir, "lists", "blocked."+tc.format))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != tc.content {
+				t.Errorf("got %q, want %q", content, tc.content)
+			}
+
+			file := hclwrite.NewEmptyFile()
+			render_resource(file, "sigsci_corp_list", "blocked", config)
+			if !strings.Contains(string(file.Bytes()), "entries     = "+tc.expr) {
+				t.Errorf("entries not read from the data file:\n%s", file.Bytes())
+			}
+		})
+	}
+}
+
+func TestExternalizeKeepsEmptyListsInline(t *testing.T) {
+	dir := t.TempDir()
+	config := list_config("", sigsci.CreateListBody{Name: "Empty", Type: "ip"})
+	if err := externalize_list_entries(config, dir, "empty", listFilesTxt); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "lists")); !os.IsNotExist(err) {
+		t.Error("data file written for an empty list")
+	}
+}
+
+func TestCompactListFilesGolden(t *testing.T) {
+	_, sc, api := newFakeAPI(t, "testcorp")
+	outputDir := t.TempDir()
+
+	opts := RunOptions{Format: formatHCL, Output: outputTerraform, Compact: true, ListFiles: listFilesTxt}
+	if err := terraformify_corp(context.Background(), sc, api, "testcorp", outputDir, opts, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	assertGolden(t, filepath.Join(outputDir, "import.tf"), filepath.Join("testcorp", "compact-list-files", "import.tf"))
+	assertGolden(t, filepath.Join(outputDir, "lists", "corpdotblocked-ips.txt"), filepath.Join("testcorp", "compact-list-files", "lists", "corpdotblocked-ips.txt"))
+	assertGolden(t, filepath.Join(outputDir, "lists", "wwwsitedotoffice-ips.txt"), filepath.Join("testcorp", "compact-list-files", "lists", "wwwsitedotoffice-ips.txt"))
+}
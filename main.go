@@ -1,519 +1,491 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
-	"io"
-	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"slices"
 	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
-	"github.com/hashicorp/hcl/v2"
-	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/hashicorp/hcl/v2/hclwrite"
 	sigsci "github.com/signalsciences/go-sigsci"
-	"github.com/zclconf/go-cty/cty"
 )
 
 func main() {
 	email := os.Getenv("TF_VAR_NGWAF_EMAIL")
 	token := os.Getenv("TF_VAR_NGWAF_TOKEN")
 	sc := sigsci.NewTokenClient(email, token)
+	api := NewAPIClient(email, token)
+	if err := LoadTransportConfig().Apply(api); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	// Stop cleanly on Ctrl-C instead of leaving half-written files behind
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Subcommands, anything else is the default import run
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		var err error
+		switch os.Args[1] {
+		case "promote":
+			err = run_promote(os.Args[2:])
+		case "cleanup":
+			err = run_cleanup(os.Args[2:])
+		case "verify":
+			err = run_verify(ctx, sc, api, os.Args[2:])
+		case "payloads":
+			err = run_payloads(ctx, sc, api, os.Args[2:])
+		case "lint":
+			err = run_lint(ctx, sc, api, os.Args[2:])
+		case "simulate":
+			err = run_simulate(ctx, sc, api, os.Args[2:])
+		case "backup":
+			err = run_backup(ctx, sc, api, os.Args[2:])
+		case "restore":
+			err = run_restore(ctx, sc, api, os.Args[2:])
+		case "rule-tests":
+			err = run_rule_tests(ctx, sc, api, os.Args[2:])
+		case "migrate-templates":
+			err = run_migrate_templates(ctx, sc, api, os.Args[2:])
+		default:
+			err = fmt.Errorf("unknown command %q", os.Args[1])
+		}
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		fmt.Println("done")
+		return
+	}
+
+	configPath := flag.String("config", "", "JSON file listing the corps to process, see README")
+	var opts RunOptions
+	flag.StringVar(&opts.Format, "format", formatHCL, "output syntax, hcl or json (.tf.json)")
+	flag.BoolVar(&opts.Compact, "compact", false, "one for_each import block and resource per resource type (Terraform 1.7+)")
+	flag.StringVar(&opts.ListFiles, "list-files", "", "with -compact, write list entries to lists/<name>.txt or .json (txt or json)")
+	backendConfig := keyValueFlag{}
+	opts.BackendConfig = backendConfig
+	flag.StringVar(&opts.ProviderVersion, "provider-version", defaultProviderVersion, "sigsci provider version constraint of the generated providers.tf")
+	flag.StringVar(&opts.Backend, "backend", "", "state backend of the generated root module: local, s3 or http (default none)")
+	flag.Var(backendConfig, "backend-config", "backend argument as key=value, repeatable; {corp} is replaced with the corp name")
+	flag.BoolVar(&opts.Anonymize, "anonymize", false, "pseudonymize IPs, hostnames, URLs and emails, for sharing; keyed with NGWAF_ANONYMIZE_KEY, random when unset")
+	flag.BoolVar(&opts.IncludeDisabledTemplates, "include-disabled-templates", false, "also import legacy templated rules without detections, so they stay disabled")
+	flag.Var((*stringsFlag)(&opts.Policies), "policy", "policy file or directory of policy files to check the objects against, repeatable; the run fails on violations")
+	flag.StringVar(&opts.Output, "output", outputTerraform, "terraform (import blocks), import-script (terraform import commands for Terraform < 1.5), cdktf-typescript, cdktf-go or pulumi-yaml")
+	flag.Parse()
+
+	if err := opts.validate(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	if *configPath != "" {
+		cfg, err := LoadConfig(*configPath)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if err := terraformify_corps(ctx, api, cfg, opts); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		fmt.Println("done")
+		return
+	}
 
 	corp := os.Getenv("TF_VAR_NGWAF_CORP")
 
+	if err := terraformify_corp(ctx, sc, api, corp, ".", opts, ""); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	// The corp comes from TF_VAR_NGWAF_CORP, as the tool read it
+	if err := write_corp_root_module(".", corp, opts, RootModule{}); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	fmt.Println("done")
+
+}
+
+// RunOptions selects what a run writes
+type RunOptions struct {
+	// Format is the Terraform syntax, formatHCL or formatJSON
+	Format string
+	// Output is outputTerraform, outputImportScript, outputCdktfTS,
+	// outputCdktfGo or outputPulumiYAML
+	Output string
+	// Compact writes one for_each import block and resource per resource
+	// type instead of a block per object
+	Compact bool
+	// ListFiles, when set, externalizes the entries of rendered lists to
+	// data files of that format, listFilesTxt or listFilesJSON
+	ListFiles string
+	// ProviderVersion, Backend and BackendConfig shape the generated
+	// root module, see RootModule
+	ProviderVersion string
+	Backend         string
+	BackendConfig   map[string]string
+	// Anonymize pseudonymizes identifying values of the fetched objects,
+	// see anonymize_snapshot
+	Anonymize bool
+	// Policies are policy files or directories the fetched objects must
+	// satisfy, see check_policies
+	Policies []string
+	// IncludeDisabledTemplates also imports legacy templated rules without
+	// detections, so they stay disabled
+	IncludeDisabledTemplates bool
+}
+
+func (opts RunOptions) validate() error {
+	if err := validFormat(opts.Format); err != nil {
+		return err
+	}
+	if err := validBackend(opts.Backend); err != nil {
+		return err
+	}
+	if opts.Compact && opts.Output != outputTerraform {
+		return fmt.Errorf("-compact needs -output=%s", outputTerraform)
+	}
+	// CDKTF and Pulumi programs are written in their own language
+	if opts.Format == formatJSON && isProgramOutput(opts.Output) {
+		return fmt.Errorf("-format=json needs -output=%s or %s", outputTerraform, outputImportScript)
+	}
+	if err := validListFiles(opts.ListFiles); err != nil {
+		return err
+	}
+	// Without -compact lists are rendered by terraform, not by this tool
+	if opts.ListFiles != "" && !opts.Compact {
+		return fmt.Errorf("-list-files needs -compact")
+	}
+	return validOutput(opts.Output)
+}
+
+// write_corp_root_module completes dir, holding the imports of corp, into a
+// runnable root module. module supplies the corp specific settings, opts the
+// rest. CDKTF and Pulumi programs configure their provider themselves.
+func write_corp_root_module(dir string, corp string, opts RunOptions, module RootModule) error {
+	if isProgramOutput(opts.Output) {
+		return nil
+	}
+	module.ProviderVersion = opts.ProviderVersion
+	module.Backend = opts.Backend
+	module.BackendConfig = opts.BackendConfig
+	if err := write_root_module(dir, corp, module); err != nil {
+		return err
+	}
+	if opts.Format == formatJSON {
+		return convert_terraform_files_to_json(dir, "providers.tf", "variables.tf")
+	}
+	return nil
+}
+
+// terraformify_corps processes every corp in cfg, each into its own output
+// directory with its own aliased provider. api supplies the shared
+// transport settings; credentials come from each corp's entry.
+func terraformify_corps(ctx context.Context, api *APIClient, cfg Config, opts RunOptions) error {
+	for _, corp := range cfg.Corps {
+		email, token, err := corp.Credentials()
+		if err != nil {
+			return err
+		}
+
+		if err := os.MkdirAll(corp.OutputDir, 0755); err != nil {
+			return fmt.Errorf("corp %s: %v", corp.Name, err)
+		}
+
+		corpAPI := *api
+		corpAPI.Email = email
+		corpAPI.Token = token
+
+		fmt.Printf("corp %s -> %s\n", corp.Name, corp.OutputDir)
+		if err := terraformify_corp(ctx, sigsci.NewTokenClient(email, token), &corpAPI, corp.Name, corp.OutputDir, opts, corp.ProviderAlias); err != nil {
+			return fmt.Errorf("corp %s: %v", corp.Name, err)
+		}
+		module := RootModule{Corp: corp.Name, ProviderAlias: corp.ProviderAlias}
+		if err := write_corp_root_module(corp.OutputDir, corp.Name, opts, module); err != nil {
+			return fmt.Errorf("corp %s: %v", corp.Name, err)
+		}
+	}
+	return nil
+}
+
+// terraformify_corp writes the imports for every object in corp to
+// outputDir, skipping IDs already in outputDir/terraform.tfstate.
+// providerAlias, when set, selects the aliased sigsci provider.
+func terraformify_corp(ctx context.Context, sc sigsci.Client, api *APIClient, corp string, outputDir string, opts RunOptions, providerAlias string) error {
+	snapshot, err := fetch_corp_snapshot(ctx, sc, api, corp)
+	if err != nil {
+		return err
+	}
+	if opts.Anonymize {
+		anonymize_snapshot(&snapshot, new_anonymizer(os.Getenv("NGWAF_ANONYMIZE_KEY")))
+	}
+	if !opts.IncludeDisabledTemplates {
+		drop_disabled_templates(&snapshot)
+	}
+	if err := check_policies(snapshot, opts.Policies, time.Now()); err != nil {
+		return err
+	}
+	for _, alert := range unrepresented_alerts(snapshot) {
+		fmt.Println("WARNING:", alert)
+	}
+
+	if opts.Compact {
+		// The for_each resources must list every object, including those
+		// already in state, or Terraform would plan to destroy them.
+		// Imports of managed objects are no-ops.
+		fileName := filepath.Join(outputDir, "import.tf")
+		if err := write_compact_import_blocks(import_set_from_snapshot(snapshot, nil), fileName, providerAlias, opts.ListFiles); err != nil {
+			return err
+		}
+		if opts.Format == formatJSON {
+			return convert_terraform_files_to_json(outputDir, "import.tf", "secrets.tf")
+		}
+		return nil
+	}
+
+	imports := state_import_set(snapshot, outputDir)
+
+	switch opts.Output {
+	case outputImportScript:
+		return write_import_script(imports, outputDir)
+	case outputCdktfTS, outputCdktfGo:
+		// A subdirectory keeps the generated main.go apart from this tool's
+		cdktfDir := filepath.Join(outputDir, "cdktf")
+		if err := os.MkdirAll(cdktfDir, 0755); err != nil {
+			return err
+		}
+		if opts.Output == outputCdktfGo {
+			return write_cdktf_go(imports, corp, cdktfDir)
+		}
+		return write_cdktf_typescript(imports, corp, cdktfDir)
+	case outputPulumiYAML:
+		pulumiDir := filepath.Join(outputDir, "pulumi")
+		if err := os.MkdirAll(pulumiDir, 0755); err != nil {
+			return err
+		}
+		return write_pulumi_yaml(imports, corp, pulumiDir)
+	default:
+		if !write_import_blocks(imports, filepath.Join(outputDir, "import.tf"), providerAlias) {
+			return fmt.Errorf("error writing import.tf")
+		}
+		if opts.Format == formatJSON {
+			return convert_terraform_files_to_json(outputDir, "import.tf", "secrets.tf")
+		}
+	}
+	return nil
+}
+
+// state_import_set lists the objects of snapshot not yet in
+// outputDir/terraform.tfstate
+func state_import_set(snapshot CorpSnapshot, outputDir string) *ImportSet {
 	existing_terraform_ids, err := ExtractTerraformStateIDs(
-		filepath.Join(".", "terraform.tfstate"),
+		filepath.Join(outputDir, "terraform.tfstate"),
 		"",
 	)
 	if err != nil {
 		fmt.Println(err)
 	}
 
-	// Corp imports
-	allCorpRules, _ := sc.GetAllCorpRules(corp)
-	set_import_corp_rule_resources(allCorpRules, existing_terraform_ids)
+	return import_set_from_snapshot(snapshot, existing_terraform_ids)
+}
 
-	allCorpLists, _ := sc.GetAllCorpLists(corp)
-	set_import_corp_list_resources(allCorpLists, existing_terraform_ids)
+// import_set_from_snapshot lists the objects of snapshot to import
+func import_set_from_snapshot(snapshot CorpSnapshot, existing_terraform_ids []string) *ImportSet {
+	imports := &ImportSet{}
 
-	allCorpSignals, _ := sc.GetAllCorpSignalTags(corp)
-	set_import_corp_signals_resources(allCorpSignals, existing_terraform_ids)
+	// Corp imports
+	set_import_corp_rule_resources(imports, snapshot.Rules, existing_terraform_ids)
+	set_import_corp_list_resources(imports, snapshot.Lists, existing_terraform_ids)
+	set_import_corp_signals_resources(imports, snapshot.Signals, existing_terraform_ids)
 
-	allSiteNames, _ := sc.ListSites(corp)
-	set_import_sites_resources(allSiteNames, existing_terraform_ids)
+	var allSites []sigsci.Site
+	for _, site := range snapshot.Sites {
+		allSites = append(allSites, site.Site)
+	}
+	set_import_sites_resources(imports, allSites, existing_terraform_ids)
 
 	// Site imports
-	for _, ngwafSite := range allSiteNames {
-		// Site rules
-		allSiteRules, _ := sc.GetAllSiteRules(corp, ngwafSite.Name)
-		set_import_site_rule_resources(ngwafSite.Name, allSiteRules, existing_terraform_ids)
-
-		// Site Legacy Templated Rules
-		allLegacyTemplatedRules := get_active_legacy_templated_rules(corp, ngwafSite.Name, email, token)
-		set_import_site_legacy_templated_rule_resources(ngwafSite.Name, allLegacyTemplatedRules, existing_terraform_ids)
-
-		// Site tags
-		allSiteSignals, _ := sc.GetAllSiteSignalTags(corp, ngwafSite.Name)
-		set_import_site_signals_resources(ngwafSite.Name, allSiteSignals, existing_terraform_ids)
+	for _, site := range snapshot.Sites {
+		siteName := site.Site.Name
 
-		// Site lists
-		allSiteLists, _ := sc.GetAllSiteLists(corp, ngwafSite.Name)
-		set_import_site_list_resources(ngwafSite.Name, allSiteLists, existing_terraform_ids)
-
-		allSiteIntegrations, _ := sc.ListIntegrations(corp, ngwafSite.Name)
-		set_import_site_integration_resources(ngwafSite.Name, allSiteIntegrations, existing_terraform_ids)
+		set_import_site_rule_resources(imports, siteName, site.Rules, existing_terraform_ids)
+		set_import_site_legacy_templated_rule_resources(imports, siteName, site.LegacyTemplatedRules, existing_terraform_ids)
+		set_import_site_signals_resources(imports, siteName, site.Signals, existing_terraform_ids)
+		set_import_site_list_resources(imports, siteName, site.Lists, existing_terraform_ids)
+		set_import_site_integration_resources(imports, siteName, site.Integrations, existing_terraform_ids)
 
 		// Header link integrations
-		allSiteHeaderLinks, _ := sc.ListHeaderLinks(corp, ngwafSite.Name)
-		set_import_site_header_link_resources(ngwafSite.Name, allSiteHeaderLinks, existing_terraform_ids)
-
-		// Site alerts and Agent alerts
-		allSiteAlerts, _ := sc.ListCustomAlerts(corp, ngwafSite.Name)
+		set_import_site_header_link_resources(imports, siteName, site.HeaderLinks, existing_terraform_ids)
 
-		var infoAlerts []sigsci.CustomAlert
+		// Site alerts and Agent alerts; alerts of templated rules are
+		// rendered with the rule, see classify_alert
+		var siteAlerts []sigsci.CustomAlert
 		var agentAlerts []sigsci.CustomAlert
-		for _, siteAlert := range allSiteAlerts {
-			if siteAlert.Action == "info" {
-				infoAlerts = append(infoAlerts, siteAlert)
-			}
-			if siteAlert.Action == "siteMetricInfo" {
+		for _, siteAlert := range site.Alerts {
+			switch classify_alert(siteName, site.LegacyTemplatedRules.Data, siteAlert).ResourceType {
+			case "sigsci_site_alert":
+				siteAlerts = append(siteAlerts, siteAlert)
+			case "sigsci_site_agent_alert":
 				agentAlerts = append(agentAlerts, siteAlert)
 			}
 		}
 
 		// Site agent alerts and Site alerts
-		set_import_site_agent_alerts_resources(ngwafSite.Name, agentAlerts, existing_terraform_ids)
-		set_import_site_alerts_resources(ngwafSite.Name, infoAlerts, existing_terraform_ids)
-
+		set_import_site_agent_alerts_resources(imports, siteName, agentAlerts, existing_terraform_ids)
+		set_import_site_alerts_resources(imports, siteName, siteAlerts, existing_terraform_ids)
 	}
 
-	fmt.Println("done")
-
+	return imports
 }
 
-func set_import_corp_rule_resources(allCorpRules sigsci.ResponseCorpRuleBodyList, existing_terraform_ids []string) []string {
-	var sigsciCorpIdNoNnumbersArray []string
-
-	// Create a new empty HCL file
-	file := hclwrite.NewEmptyFile()
-
+func set_import_corp_rule_resources(imports *ImportSet, allCorpRules sigsci.ResponseCorpRuleBodyList, existing_terraform_ids []string) {
 	for _, corp_rule := range allCorpRules.Data {
 		if slices.Contains(existing_terraform_ids, corp_rule.ID) {
 			continue
 		}
-		sigsciCorpIdNoNnumbers := sanitizeTfId(corp_rule.ID)
 		if corp_rule.Type == "request" {
-			// Create a new block (e.g., a resource block)
-			block := file.Body().AppendNewBlock("import", nil)
-			// Set attributes for the block
-			block.Body().SetAttributeValue("id", cty.StringVal(corp_rule.ID))
-			tokens := hclwrite.Tokens{
-				{
-					Type:  hclsyntax.TokenIdent,
-					Bytes: []byte(fmt.Sprintf(`sigsci_corp_rule.%s`, sigsciCorpIdNoNnumbers)),
-				},
-			}
-			block.Body().SetAttributeRaw("to", tokens)
-			sigsciCorpIdNoNnumbersArray = append(sigsciCorpIdNoNnumbersArray, sigsciCorpIdNoNnumbers)
+			imports.add("sigsci_corp_rule", sanitizeTfId(corp_rule.ID), corp_rule.ID, "", corp_rule)
 		}
 	}
-
-	// Open the file and write
-	write_terraform_config_to_file(file, "import.tf")
-	return sigsciCorpIdNoNnumbersArray
 }
 
 // Corp lists
-func set_import_corp_list_resources(list sigsci.ResponseListBodyList, existing_terraform_ids []string) []string {
-	var sigsciIdNoNnumbersArray []string
-
-	// Create a new empty HCL file
-	file := hclwrite.NewEmptyFile()
-
+func set_import_corp_list_resources(imports *ImportSet, list sigsci.ResponseListBodyList, existing_terraform_ids []string) {
 	for _, item := range list.Data {
 		if slices.Contains(existing_terraform_ids, item.ID) {
 			continue
 		}
-		sigsciIdNoNnumbers := sanitizeTfId(item.ID)
-		// if item.Type == "request" {
-		// Create a new block (e.g., a resource block)
-		block := file.Body().AppendNewBlock("import", nil)
-		// Set attributes for the block
-		block.Body().SetAttributeValue("id", cty.StringVal(item.ID))
-		tokens := hclwrite.Tokens{
-			{
-				Type:  hclsyntax.TokenIdent,
-				Bytes: []byte(fmt.Sprintf(`sigsci_corp_list.%s`, sigsciIdNoNnumbers)),
-			},
-		}
-		block.Body().SetAttributeRaw("to", tokens)
-		sigsciIdNoNnumbersArray = append(sigsciIdNoNnumbersArray, sigsciIdNoNnumbers)
-	}
-
-	// Open the file and write
-	fileImportTf, _ := os.OpenFile("import.tf", os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0666)
-	if _, err := file.WriteTo(fileImportTf); err != nil {
-		fmt.Println(`Error writing HCL:`, err)
-		os.Exit(1)
+		imports.add("sigsci_corp_list", sanitizeTfId(item.ID), item.ID, "", item)
 	}
-	defer fileImportTf.Close()
-	return sigsciIdNoNnumbersArray
 }
 
 // Corp Signals
-func set_import_corp_signals_resources(allCorpList sigsci.ResponseSignalTagBodyList, existing_terraform_ids []string) []string {
-	var sigsciIdNoNnumbersArray []string
-
-	// Create a new empty HCL file
-	file := hclwrite.NewEmptyFile()
-
+func set_import_corp_signals_resources(imports *ImportSet, allCorpList sigsci.ResponseSignalTagBodyList, existing_terraform_ids []string) {
 	for _, item := range allCorpList.Data {
 		if slices.Contains(existing_terraform_ids, item.TagName) {
 			continue
 		}
-		sigsciIdNoNnumbers := sanitizeTfId(item.TagName)
-		block := file.Body().AppendNewBlock("import", nil)
-		// Set attributes for the block
-		block.Body().SetAttributeValue("id", cty.StringVal(item.TagName))
-		tokens := hclwrite.Tokens{
-			{
-				Type:  hclsyntax.TokenIdent,
-				Bytes: []byte(fmt.Sprintf(`sigsci_corp_signal_tag.%s`, sigsciIdNoNnumbers)),
-			},
-		}
-		block.Body().SetAttributeRaw("to", tokens)
-		sigsciIdNoNnumbersArray = append(sigsciIdNoNnumbersArray, sigsciIdNoNnumbers)
+		imports.add("sigsci_corp_signal_tag", sanitizeTfId(item.TagName), item.TagName, "", item)
 	}
-
-	// Open the file and write
-	write_terraform_config_to_file(file, "import.tf")
-	return sigsciIdNoNnumbersArray
 }
 
 // Sites
-func set_import_sites_resources(allCorpList []sigsci.Site, existing_terraform_ids []string) []string {
-	var sigsciIdNoNnumbersArray []string
-
-	// Create a new empty HCL file
-	file := hclwrite.NewEmptyFile()
-
+func set_import_sites_resources(imports *ImportSet, allCorpList []sigsci.Site, existing_terraform_ids []string) {
 	for _, item := range allCorpList {
 		if slices.Contains(existing_terraform_ids, item.Name) {
 			continue
 		}
-		sigsciIdNoNnumbers := sanitizeTfId(item.Name)
-		block := file.Body().AppendNewBlock("import", nil)
-		// Set attributes for the block
-		block.Body().SetAttributeValue("id", cty.StringVal(item.Name))
-		tokens := hclwrite.Tokens{
-			{
-				Type:  hclsyntax.TokenIdent,
-				Bytes: []byte(fmt.Sprintf(`sigsci_site.%s`, sigsciIdNoNnumbers)),
-			},
-		}
-		block.Body().SetAttributeRaw("to", tokens)
-		sigsciIdNoNnumbersArray = append(sigsciIdNoNnumbersArray, sigsciIdNoNnumbers)
+		imports.add("sigsci_site", sanitizeTfId(item.Name), item.Name, "", item)
 	}
-
-	// Open the file and write
-	write_terraform_config_to_file(file, "import.tf")
-
-	return sigsciIdNoNnumbersArray
 }
 
 // Site lists
-func set_import_site_list_resources(ngwafSiteShortName string, list sigsci.ResponseListBodyList, existing_terraform_ids []string) []string {
-	var sigsciIdNoNnumbersArray []string
-
-	// Create a new empty HCL file
-	file := hclwrite.NewEmptyFile()
-
+func set_import_site_list_resources(imports *ImportSet, ngwafSiteShortName string, list sigsci.ResponseListBodyList, existing_terraform_ids []string) {
 	for _, item := range list.Data {
 		if slices.Contains(existing_terraform_ids, item.ID) {
 			continue
 		}
-		sigsciIdNoNnumbers := sanitizeTfId(item.ID)
-		// Create a new block (e.g., a resource block)
-		block := file.Body().AppendNewBlock("import", nil)
-		// Set attributes for the block
-		block.Body().SetAttributeValue("id", cty.StringVal(fmt.Sprintf(`%s:%s`, ngwafSiteShortName, item.ID)))
-		tokens := hclwrite.Tokens{
-			{
-				Type:  hclsyntax.TokenIdent,
-				Bytes: []byte(fmt.Sprintf(`sigsci_site_list.%s%s`, ngwafSiteShortName, sigsciIdNoNnumbers)),
-			},
-		}
-		block.Body().SetAttributeRaw("to", tokens)
-		sigsciIdNoNnumbersArray = append(sigsciIdNoNnumbersArray, sigsciIdNoNnumbers)
+		imports.add("sigsci_site_list", ngwafSiteShortName+sanitizeTfId(item.ID), fmt.Sprintf(`%s:%s`, ngwafSiteShortName, item.ID), ngwafSiteShortName, item)
 	}
-
-	// Open the file and write
-	write_terraform_config_to_file(file, "import.tf")
-	return sigsciIdNoNnumbersArray
 }
 
-// Site alerts
-func set_import_site_integration_resources(ngwafSiteShortName string, list []sigsci.Integration, existing_terraform_ids []string) []string {
-	var sigsciIdNoNnumbersArray []string
-
-	// Create a new empty HCL file
-	file := hclwrite.NewEmptyFile()
-
+// Site integrations
+func set_import_site_integration_resources(imports *ImportSet, ngwafSiteShortName string, list []sigsci.Integration, existing_terraform_ids []string) {
 	for _, item := range list {
 		if slices.Contains(existing_terraform_ids, item.ID) {
 			continue
 		}
-		sigsciIdNoNnumbers := sanitizeTfId(item.ID)
-		// Create a new block (e.g., a resource block)
-		block := file.Body().AppendNewBlock("import", nil)
-		// Set attributes for the block
-		block.Body().SetAttributeValue("id", cty.StringVal(fmt.Sprintf(`%s:%s`, ngwafSiteShortName, item.ID)))
-		tokens := hclwrite.Tokens{
-			{
-				Type:  hclsyntax.TokenIdent,
-				Bytes: []byte(fmt.Sprintf(`sigsci_site_integration.%s`, sigsciIdNoNnumbers)),
-			},
-		}
-		block.Body().SetAttributeRaw("to", tokens)
-		sigsciIdNoNnumbersArray = append(sigsciIdNoNnumbersArray, sigsciIdNoNnumbers)
+		imports.add("sigsci_site_integration", sanitizeTfId(item.ID), fmt.Sprintf(`%s:%s`, ngwafSiteShortName, item.ID), ngwafSiteShortName, item)
 	}
-
-	// Open the file and write
-	write_terraform_config_to_file(file, "import.tf")
-	return sigsciIdNoNnumbersArray
 }
 
 // Site alerts
-func set_import_site_alerts_resources(ngwafSiteShortName string, list []sigsci.CustomAlert, existing_terraform_ids []string) []string {
-	var sigsciIdNoNnumbersArray []string
-
-	// Create a new empty HCL file
-	file := hclwrite.NewEmptyFile()
-
+func set_import_site_alerts_resources(imports *ImportSet, ngwafSiteShortName string, list []sigsci.CustomAlert, existing_terraform_ids []string) {
 	for _, item := range list {
 		if slices.Contains(existing_terraform_ids, item.ID) {
 			continue
 		}
-		sigsciIdNoNnumbers := sanitizeTfId(item.ID)
-		// Create a new block (e.g., a resource block)
-		block := file.Body().AppendNewBlock("import", nil)
-		// Set attributes for the block
-		block.Body().SetAttributeValue("id", cty.StringVal(fmt.Sprintf(`%s:%s`, ngwafSiteShortName, item.ID)))
-		tokens := hclwrite.Tokens{
-			{
-				Type:  hclsyntax.TokenIdent,
-				Bytes: []byte(fmt.Sprintf(`sigsci_site_alert.%s`, sigsciIdNoNnumbers)),
-			},
-		}
-		block.Body().SetAttributeRaw("to", tokens)
-		sigsciIdNoNnumbersArray = append(sigsciIdNoNnumbersArray, sigsciIdNoNnumbers)
+		imports.add("sigsci_site_alert", sanitizeTfId(item.ID), fmt.Sprintf(`%s:%s`, ngwafSiteShortName, item.ID), ngwafSiteShortName, item)
 	}
-
-	// Open the file and write
-	write_terraform_config_to_file(file, "import.tf")
-	return sigsciIdNoNnumbersArray
 }
 
 // Site agent alerts
-func set_import_site_agent_alerts_resources(ngwafSiteShortName string, list []sigsci.CustomAlert, existing_terraform_ids []string) []string {
-	var sigsciIdNoNnumbersArray []string
-
-	// Create a new empty HCL file
-	file := hclwrite.NewEmptyFile()
-
+func set_import_site_agent_alerts_resources(imports *ImportSet, ngwafSiteShortName string, list []sigsci.CustomAlert, existing_terraform_ids []string) {
 	for _, item := range list {
 		if slices.Contains(existing_terraform_ids, item.ID) {
 			continue
 		}
-		sigsciIdNoNnumbers := sanitizeTfId(item.ID)
-		// Create a new block (e.g., a resource block)
-		block := file.Body().AppendNewBlock("import", nil)
-		// Set attributes for the block
-		block.Body().SetAttributeValue("id", cty.StringVal(fmt.Sprintf(`%s:%s`, ngwafSiteShortName, item.ID)))
-		tokens := hclwrite.Tokens{
-			{
-				Type:  hclsyntax.TokenIdent,
-				Bytes: []byte(fmt.Sprintf(`sigsci_site_agent_alert.%s`, sigsciIdNoNnumbers)),
-			},
-		}
-		block.Body().SetAttributeRaw("to", tokens)
-		sigsciIdNoNnumbersArray = append(sigsciIdNoNnumbersArray, sigsciIdNoNnumbers)
+		imports.add("sigsci_site_agent_alert", sanitizeTfId(item.ID), fmt.Sprintf(`%s:%s`, ngwafSiteShortName, item.ID), ngwafSiteShortName, item)
 	}
-
-	// Open the file and write
-	write_terraform_config_to_file(file, "import.tf")
-	return sigsciIdNoNnumbersArray
 }
 
-func set_import_site_signals_resources(ngwafSiteShortName string, list sigsci.ResponseSignalTagBodyList, existing_terraform_ids []string) []string {
-	var sigsciIdNoNnumbersArray []string
-
-	// Create a new empty HCL file
-	file := hclwrite.NewEmptyFile()
-
+func set_import_site_signals_resources(imports *ImportSet, ngwafSiteShortName string, list sigsci.ResponseSignalTagBodyList, existing_terraform_ids []string) {
 	for _, item := range list.Data {
 		if slices.Contains(existing_terraform_ids, item.TagName) {
 			continue
 		}
-		sigsciIdNoNnumbers := sanitizeTfId(item.TagName)
-		block := file.Body().AppendNewBlock("import", nil)
-		// Set attributes for the block
-		block.Body().SetAttributeValue("id", cty.StringVal(fmt.Sprintf(`%s:%s`, ngwafSiteShortName, item.TagName)))
-		tokens := hclwrite.Tokens{
-			{
-				Type:  hclsyntax.TokenIdent,
-				Bytes: []byte(fmt.Sprintf(`sigsci_site_signal_tag.%s%s`, ngwafSiteShortName, sigsciIdNoNnumbers)),
-			},
-		}
-		block.Body().SetAttributeRaw("to", tokens)
-		sigsciIdNoNnumbersArray = append(sigsciIdNoNnumbersArray, sigsciIdNoNnumbers)
-		// }
+		imports.add("sigsci_site_signal_tag", ngwafSiteShortName+sanitizeTfId(item.TagName), fmt.Sprintf(`%s:%s`, ngwafSiteShortName, item.TagName), ngwafSiteShortName, item)
 	}
-
-	// Open the file and write
-	write_terraform_config_to_file(file, "import.tf")
-
-	return sigsciIdNoNnumbersArray
 }
 
-func set_import_site_rule_resources(ngwafSiteShortName string, list sigsci.ResponseSiteRuleBodyList, existing_terraform_ids []string) []string {
-	var sigsciSiteIdNoNnumbersArray []string
-
-	// Create a new empty HCL file
-	file := hclwrite.NewEmptyFile()
-
+func set_import_site_rule_resources(imports *ImportSet, ngwafSiteShortName string, list sigsci.ResponseSiteRuleBodyList, existing_terraform_ids []string) {
 	for _, item := range list.Data {
 		if slices.Contains(existing_terraform_ids, item.ID) {
 			continue
 		}
-		sigsciSiteIdNoNnumbers := sanitizeTfId(item.ID)
 
 		switch item.Type {
-		case "request":
-			// Create a new block (e.g., a resource block)
-			block := file.Body().AppendNewBlock("import", nil)
-			// Set attributes for the block
-			block.Body().SetAttributeValue("id", cty.StringVal(fmt.Sprintf(`%s:%s`, ngwafSiteShortName, item.ID)))
-			tokens := hclwrite.Tokens{
-				{
-					Type:  hclsyntax.TokenIdent,
-					Bytes: []byte(fmt.Sprintf(`sigsci_site_rule.%s`, sigsciSiteIdNoNnumbers)),
-				},
-			}
-			block.Body().SetAttributeRaw("to", tokens)
-			sigsciSiteIdNoNnumbersArray = append(sigsciSiteIdNoNnumbersArray, sigsciSiteIdNoNnumbers)
-		case "rateLimit":
-			// fmt.Printf("%+v\n", siteRule)
-			// Create a new block (e.g., a resource block)
-			block := file.Body().AppendNewBlock("import", nil)
-			// Set attributes for the block
-			block.Body().SetAttributeValue("id", cty.StringVal(fmt.Sprintf(`%s:%s`, ngwafSiteShortName, item.ID)))
-			tokens := hclwrite.Tokens{
-				{
-					Type:  hclsyntax.TokenIdent,
-					Bytes: []byte(fmt.Sprintf(`sigsci_site_rule.%s`, sigsciSiteIdNoNnumbers)),
-				},
-			}
-			block.Body().SetAttributeRaw("to", tokens)
-			sigsciSiteIdNoNnumbersArray = append(sigsciSiteIdNoNnumbersArray, sigsciSiteIdNoNnumbers)
-
-		case "templatedSignal":
-			// Create a new block (e.g., a resource block)
-			block := file.Body().AppendNewBlock("import", nil)
-			// Set attributes for the block
-			block.Body().SetAttributeValue("id", cty.StringVal(fmt.Sprintf(`%s:%s`, ngwafSiteShortName, item.ID)))
-			tokens := hclwrite.Tokens{
-				{
-					Type:  hclsyntax.TokenIdent,
-					Bytes: []byte(fmt.Sprintf(`sigsci_site_rule.%s`, sigsciSiteIdNoNnumbers)),
-				},
-			}
-			block.Body().SetAttributeRaw("to", tokens)
-			sigsciSiteIdNoNnumbersArray = append(sigsciSiteIdNoNnumbersArray, sigsciSiteIdNoNnumbers)
+		case "request", "rateLimit", "templatedSignal":
+			imports.add("sigsci_site_rule", sanitizeTfId(item.ID), fmt.Sprintf(`%s:%s`, ngwafSiteShortName, item.ID), ngwafSiteShortName, item)
 		}
 	}
-	// Open the file and write
-	write_terraform_config_to_file(file, "import.tf")
-
-	return sigsciSiteIdNoNnumbersArray
 }
 
-func set_import_site_header_link_resources(
-	ngwafSiteShortName string,
-	list []sigsci.HeaderLink,
-	existingTerraformIDs []string,
-) []string {
-	var resultIDs []string
-
-	// Create a new empty HCL file
-	file := hclwrite.NewEmptyFile()
-
+// Header link integrations
+func set_import_site_header_link_resources(imports *ImportSet, ngwafSiteShortName string, list []sigsci.HeaderLink, existing_terraform_ids []string) {
 	for _, item := range list {
-		if slices.Contains(existingTerraformIDs, item.ID) {
+		if slices.Contains(existing_terraform_ids, item.ID) {
 			continue
 		}
-
-		// Convert the tag name to something valid as a TF resource name
-		sigsciIdNoNnumbers := sanitizeTfId(item.ID)
-
-		// Create a new 'import' block
-		block := file.Body().AppendNewBlock("import", nil)
-		block.Body().SetAttributeValue("id", cty.StringVal(
-			fmt.Sprintf("%s:%s", ngwafSiteShortName, item.ID),
-		))
-
-		// Create a traversal to reference the resource in Terraform
-		traversal := hcl.Traversal{
-			hcl.TraverseRoot{Name: "sigsci_site_header_link"},
-			hcl.TraverseAttr{Name: fmt.Sprintf("%s%s", ngwafSiteShortName, sigsciIdNoNnumbers)},
-		}
-		// Use SetAttributeTraversal to set the 'to' attribute
-		block.Body().SetAttributeTraversal("to", traversal)
-
-		// Collect our sanitized IDs
-		resultIDs = append(resultIDs, sigsciIdNoNnumbers)
+		imports.add("sigsci_site_header_link", ngwafSiteShortName+sanitizeTfId(item.ID), fmt.Sprintf("%s:%s", ngwafSiteShortName, item.ID), ngwafSiteShortName, item)
 	}
-
-	// Write the file to disk
-	write_terraform_config_to_file(file, "import.tf")
-
-	return resultIDs
 }
 
-func set_import_site_legacy_templated_rule_resources(ngwafSiteShortName string, list ResponseSiteLegacyTemplatedRuleBodyList, existing_terraform_ids []string) []string {
-	var sigsciIdNoNnumbersArray []string
-
-	// Create a new empty HCL file
-	file := hclwrite.NewEmptyFile()
-
+func set_import_site_legacy_templated_rule_resources(imports *ImportSet, ngwafSiteShortName string, list ResponseSiteLegacyTemplatedRuleBodyList, existing_terraform_ids []string) {
 	for _, item := range list.Data {
 		if slices.Contains(existing_terraform_ids, item.Name) {
 			continue
 		}
-		if len(item.Detections) > 0 {
-			sigsciIdNoNnumbers := sanitizeTfId(item.Name)
-			// Create a new block (e.g., a resource block)
-			block := file.Body().AppendNewBlock("import", nil)
-			// Set attributes for the block
-			block.Body().SetAttributeValue("id", cty.StringVal(fmt.Sprintf(`%s:%s`, ngwafSiteShortName, item.Name)))
-			tokens := hclwrite.Tokens{
-				{
-					Type:  hclsyntax.TokenIdent,
-					Bytes: []byte(fmt.Sprintf(`sigsci_site_templated_rule.%s%s`, ngwafSiteShortName, sigsciIdNoNnumbers)),
-				},
+		imports.add("sigsci_site_templated_rule", ngwafSiteShortName+sanitizeTfId(item.Name), fmt.Sprintf(`%s:%s`, ngwafSiteShortName, item.Name), ngwafSiteShortName, item)
+	}
+}
+
+// drop_disabled_templates removes the legacy templated rules without
+// detections, those not configured on their site, from snapshot. Templates
+// with disabled detections only are kept, the detections codify that.
+func drop_disabled_templates(snapshot *CorpSnapshot) {
+	for s := range snapshot.Sites {
+		templates := &snapshot.Sites[s].LegacyTemplatedRules
+		var enabled []ResponseSiteLegacyTemplatedRuleBody
+		for _, template := range templates.Data {
+			if len(template.Detections) > 0 {
+				enabled = append(enabled, template)
 			}
-			block.Body().SetAttributeRaw("to", tokens)
-			sigsciIdNoNnumbersArray = append(sigsciIdNoNnumbersArray, sigsciIdNoNnumbers)
 		}
+		templates.Data = enabled
 	}
-
-	// Open the file and write
-	write_terraform_config_to_file(file, "import.tf")
-	return sigsciIdNoNnumbersArray
 }
 
 func sanitizeTfId(str string) string {
@@ -553,44 +525,19 @@ func write_terraform_config_to_file(hclFile *hclwrite.File, fileName string) boo
 	return true
 }
 
-func get_active_legacy_templated_rules(corpName string, siteName string, email string, token string) ResponseSiteLegacyTemplatedRuleBodyList {
-	// Data structure for JSON input
-
-	resp, _ := doRequestDetailed("GET", fmt.Sprintf("/v0/corps/%s/sites/%s/configuredtemplates", corpName, siteName), "", email, token)
-
-	defer resp.Body.Close()
-	body, _ := io.ReadAll(resp.Body)
-
+func get_active_legacy_templated_rules(ctx context.Context, api *APIClient, corpName string, siteName string) (ResponseSiteLegacyTemplatedRuleBodyList, error) {
 	var legacyTemplatedRuledata ResponseSiteLegacyTemplatedRuleBodyList
 
-	json.Unmarshal(body, &legacyTemplatedRuledata)
-
-	return legacyTemplatedRuledata
-}
-
-func doRequestDetailed(method string, url string, reqBody string, email string, token string) (*http.Response, error) {
-	apiURL := "https://dashboard.signalsciences.net/api"
-	client := &http.Client{}
-
-	var b io.Reader
-	if reqBody != "" {
-		b = strings.NewReader(reqBody)
+	body, err := api.Do(ctx, "GET", fmt.Sprintf("/v0/corps/%s/sites/%s/configuredtemplates", corpName, siteName), "")
+	if err != nil {
+		return legacyTemplatedRuledata, err
 	}
 
-	req, _ := http.NewRequest(method, apiURL+url, b)
-
-	if email != "" {
-		// token auth
-		req.Header.Set("X-API-User", email)
-		req.Header.Set("X-API-Token", token)
+	if err := json.Unmarshal(body, &legacyTemplatedRuledata); err != nil {
+		return legacyTemplatedRuledata, fmt.Errorf("error parsing configured templates for site %s: %v", siteName, err)
 	}
 
-	req.Header.Add("Content-Type", "application/json")
-	req.Header.Set("User-Agent", "go-sigsci")
-
-	resp, err := client.Do(req)
-
-	return resp, err
+	return legacyTemplatedRuledata, nil
 }
 
 type ResponseSiteLegacyTemplatedRuleBodyList struct {
@@ -602,6 +549,8 @@ type ResponseSiteLegacyTemplatedRuleBody struct {
 	CreateSiteLegacyTemplatedRuleBody
 	Name       string      `json:"name"`
 	Detections []Detection `json:"detections"`
+	// Alerts are the alerts on the template's signal
+	Alerts []sigsci.CustomAlert `json:"alerts"`
 }
 
 type CreateSiteLegacyTemplatedRuleBody struct {
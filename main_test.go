@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"Documents/mygit/ngwaf-terraformify/internal/fakeapi"
+	sigsci "github.com/signalsciences/go-sigsci"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files")
+
+var terraformOptions = RunOptions{Format: formatHCL, Output: outputTerraform}
+
+// newFakeAPI starts a fake API seeded from testdata/fixtures/<name>.json for
+// each name and points both clients at it
+func newFakeAPI(t *testing.T, names ...string) (*fakeapi.Server, sigsci.Client, *APIClient) {
+	t.Helper()
+
+	fixture := fakeapi.Fixture{}
+	for _, name := range names {
+		loaded, err := fakeapi.LoadFixture(filepath.Join("testdata", "fixtures", name+".json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for key, items := range loaded {
+			fixture[key] = items
+		}
+	}
+	return newFakeAPIFromFixture(t, fixture)
+}
+
+func newFakeAPIFromFixture(t *testing.T, fixture fakeapi.Fixture) (*fakeapi.Server, sigsci.Client, *APIClient) {
+	t.Helper()
+
+	server := fakeapi.New(fixture)
+	t.Cleanup(server.Close)
+
+	api := NewAPIClient("test@example.com", "test-token")
+	api.RetryWait = 0
+	if err := (TransportConfig{APIURL: server.APIURL()}).Apply(api); err != nil {
+		t.Fatal(err)
+	}
+	return server, sigsci.NewTokenClient("test@example.com", "test-token"), api
+}
+
+// assertGolden compares the file at path with testdata/golden/<name>,
+// rewriting the golden file when -update is set
+func assertGolden(t *testing.T, path string, name string) {
+	t.Helper()
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden := filepath.Join("testdata", "golden", name)
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(golden), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(golden, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("%v (run go test -update to create it)", err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("%s differs from %s\n--- got ---\n%s\n--- want ---\n%s", path, golden, got, want)
+	}
+}
+
+func TestRunOptionsValidate(t *testing.T) {
+	for _, c := range []struct {
+		opts  RunOptions
+		valid bool
+	}{
+		{RunOptions{Format: formatHCL, Output: outputTerraform}, true},
+		{RunOptions{Format: formatJSON, Output: outputTerraform}, true},
+		{RunOptions{Format: formatJSON, Output: outputImportScript}, true},
+		{RunOptions{Format: formatHCL, Output: outputCdktfTS}, true},
+		{RunOptions{Format: formatJSON, Output: outputCdktfTS}, false},
+		{RunOptions{Format: formatJSON, Output: outputCdktfGo}, false},
+		{RunOptions{Format: formatHCL, Output: outputPulumiYAML}, true},
+		{RunOptions{Format: formatJSON, Output: outputPulumiYAML}, false},
+		{RunOptions{Format: formatHCL, Output: outputCdktfGo, Compact: true}, false},
+	} {
+		if err := c.opts.validate(); (err == nil) != c.valid {
+			t.Errorf("%+v: validate() = %v", c.opts, err)
+		}
+	}
+}
+
+func TestTerraformifyCorpGolden(t *testing.T) {
+	_, sc, api := newFakeAPI(t, "testcorp")
+	outputDir := t.TempDir()
+
+	if err := terraformify_corp(context.Background(), sc, api, "testcorp", outputDir, terraformOptions, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	assertGolden(t, filepath.Join(outputDir, "import.tf"), filepath.Join("testcorp", "import.tf"))
+}
+
+func TestTerraformifyCorpSkipsExistingState(t *testing.T) {
+	_, sc, api := newFakeAPI(t, "testcorp")
+	outputDir := t.TempDir()
+
+	state := `{"version": 4, "resources": [
+		{"type": "sigsci_site", "name": "www", "instances": [{"attributes": {"id": "www"}}]},
+		{"type": "sigsci_corp_list", "name": "corpdotblocked-ips", "instances": [{"attributes": {"id": "corp.blocked-ips"}}]}
+	]}`
+	if err := os.WriteFile(filepath.Join(outputDir, "terraform.tfstate"), []byte(state), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := terraformify_corp(context.Background(), sc, api, "testcorp", outputDir, terraformOptions, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(outputDir, "import.tf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(content), "sigsci_site.www\n") {
+		t.Error("site www is already in state but was imported again")
+	}
+	if strings.Contains(string(content), `"corp.blocked-ips"`) {
+		t.Error("corp list is already in state but was imported again")
+	}
+	if !strings.Contains(string(content), "sigsci_site.apiC") {
+		t.Error("site api2 is missing from import.tf")
+	}
+}
+
+func TestTerraformifyCorpDisabledTemplates(t *testing.T) {
+	_, sc, api := newFakeAPI(t, "testcorp")
+
+	for _, include := range []bool{false, true} {
+		outputDir := t.TempDir()
+		opts := terraformOptions
+		opts.IncludeDisabledTemplates = include
+		if err := terraformify_corp(context.Background(), sc, api, "testcorp", outputDir, opts, ""); err != nil {
+			t.Fatal(err)
+		}
+		content, err := os.ReadFile(filepath.Join(outputDir, "import.tf"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Contains(string(content), `"www:CCARD"`); got != include {
+			t.Errorf("with IncludeDisabledTemplates %v, template CCARD imported: %v", include, got)
+		}
+	}
+}
+
+func TestTerraformifyCorpCancelled(t *testing.T) {
+	_, sc, api := newFakeAPI(t, "testcorp")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := terraformify_corp(ctx, sc, api, "testcorp", t.TempDir(), terraformOptions, ""); err == nil {
+		t.Error("expected an error for a cancelled context")
+	}
+}
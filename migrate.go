@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	sigsci "github.com/signalsciences/go-sigsci"
+)
+
+// legacyTemplateFields map the detection fields of legacy templated rules
+// to the condition fields of site rules
+var legacyTemplateFields = map[string]string{
+	"path":         "path",
+	"method":       "method",
+	"domain":       "domain",
+	"responseCode": "responseCode",
+}
+
+// templated_rule_migration translates the detections of a legacy templated
+// rule to templatedSignal site rules, keeping the detection IDs, and its
+// alerts to site alerts. It returns what cannot be translated.
+func templated_rule_migration(template ResponseSiteLegacyTemplatedRuleBody) ([]sigsci.ResponseSiteRuleBody, []sigsci.CustomAlert, []string) {
+	var rules []sigsci.ResponseSiteRuleBody
+	var alerts []sigsci.CustomAlert
+	var warnings []string
+
+	if len(template.Detections) == 0 && len(template.Alerts) == 0 {
+		warnings = append(warnings, fmt.Sprintf("templated rule %s has no detections or alerts, nothing to migrate", template.Name))
+	}
+	for _, detection := range template.Detections {
+		owner := fmt.Sprintf("templated rule %s, detection %s", template.Name, detection.ID)
+		rule := sigsci.CreateSiteRuleBody{
+			Type:          "templatedSignal",
+			Enabled:       detection.Enabled,
+			GroupOperator: "all",
+			Reason:        "Migrated from templated rule " + template.Name,
+			Signal:        template.Name,
+			Actions:       []sigsci.Action{{Type: "addSignal", Signal: template.Name}},
+		}
+		translated := true
+		for _, field := range detection.Fields {
+			conditionField, ok := legacyTemplateFields[field.Name]
+			if !ok || field.Value == "" {
+				warnings = append(warnings, fmt.Sprintf("%s: field %s = %q has no rule condition equivalent", owner, field.Name, field.Value))
+				translated = false
+				continue
+			}
+			operator := "equals"
+			if strings.Contains(field.Value, "*") {
+				operator = "like"
+			}
+			rule.Conditions = append(rule.Conditions, sigsci.Condition{Type: "single", Field: conditionField, Operator: operator, Value: field.Value})
+		}
+		if len(rule.Conditions) == 0 {
+			// A templatedSignal rule without conditions would tag every request
+			warnings = append(warnings, fmt.Sprintf("%s: no translatable fields, not migrated", owner))
+			continue
+		}
+		if !translated {
+			warnings = append(warnings, fmt.Sprintf("%s: migrated without its untranslatable fields, it matches more requests", owner))
+		}
+		rules = append(rules, sigsci.ResponseSiteRuleBody{ID: detection.ID, CreateSiteRuleBody: rule})
+	}
+
+	for _, alert := range template.Alerts {
+		if alertResourceTypes[alert.Action] != "sigsci_site_alert" {
+			warnings = append(warnings, fmt.Sprintf("templated rule %s, alert %s (%s) has action %q, which site alerts do not support, not migrated", template.Name, alert.ID, alert.LongName, alert.Action))
+			continue
+		}
+		alert.TagName = template.Name
+		alerts = append(alerts, alert)
+	}
+	return rules, alerts, warnings
+}
+
+// migrate_templated_rules renders the legacy templated rules of the sites of
+// snapshot, or of site only, as templatedSignal site rules and site alerts
+// in outputDir/migrate.tf. Detections and alerts the site already has an
+// equivalent of, outside of its templated rules, are skipped. It returns
+// what could not be migrated.
+func migrate_templated_rules(snapshot CorpSnapshot, site string, outputDir string) ([]string, error) {
+	var warnings []string
+	file := hclwrite.NewEmptyFile()
+	found := false
+	for _, siteSnapshot := range snapshot.Sites {
+		siteName := siteSnapshot.Site.Name
+		if site != "" && siteName != site {
+			continue
+		}
+		found = true
+		// The templated rules' own alerts are what is being migrated, not
+		// equivalents of it
+		var siteAlerts []sigsci.CustomAlert
+		for _, alert := range siteSnapshot.Alerts {
+			if classify_alert(siteName, siteSnapshot.LegacyTemplatedRules.Data, alert).Owner == "" {
+				siteAlerts = append(siteAlerts, alert)
+			}
+		}
+		for _, template := range siteSnapshot.LegacyTemplatedRules.Data {
+			rules, alerts, templateWarnings := templated_rule_migration(template)
+			warnings = append(warnings, templateWarnings...)
+
+			for i, rule := range rules {
+				if has_equivalent_rule(siteSnapshot.Rules.Data, rule.CreateSiteRuleBody) {
+					warnings = append(warnings, fmt.Sprintf("templated rule %s, detection %s: site %s already has an equivalent rule, not migrated", template.Name, rule.ID, siteName))
+					continue
+				}
+				name := sanitizeTfId(rule.ID)
+				if rule.ID == "" {
+					name = fmt.Sprintf("%s%s%d", siteName, sanitizeTfId(template.Name), i)
+				}
+				render_site_rule_resource(file, name, siteName, rule.CreateSiteRuleBody, nil)
+			}
+			for i, alert := range alerts {
+				if has_equivalent_alert(siteAlerts, alert) {
+					warnings = append(warnings, fmt.Sprintf("templated rule %s, alert %s: site %s already has an equivalent alert, not migrated", template.Name, alert.ID, siteName))
+					continue
+				}
+				name := sanitizeTfId(alert.ID)
+				if alert.ID == "" {
+					name = fmt.Sprintf("%s%salert%d", siteName, sanitizeTfId(template.Name), i)
+				}
+				render_site_alert_resource(file, name, siteName, alert, nil)
+			}
+		}
+	}
+	if site != "" && !found {
+		return nil, fmt.Errorf("corp %s has no site %s", snapshot.Corp, site)
+	}
+
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return nil, err
+	}
+	if err := write_terraform_config_file(file, filepath.Join(outputDir, "migrate.tf")); err != nil {
+		return nil, err
+	}
+	return warnings, nil
+}
+
+func has_equivalent_rule(rules []sigsci.ResponseSiteRuleBody, rule sigsci.CreateSiteRuleBody) bool {
+	for _, existing := range rules {
+		if same_conditions(existing.CreateSiteRuleBody, rule) && same_rule(existing.CreateSiteRuleBody, rule) {
+			return true
+		}
+	}
+	return false
+}
+
+func has_equivalent_alert(alerts []sigsci.CustomAlert, alert sigsci.CustomAlert) bool {
+	for _, existing := range alerts {
+		if existing.TagName == alert.TagName && existing.Action == alert.Action &&
+			existing.Interval == alert.Interval && existing.Threshold == alert.Threshold {
+			return true
+		}
+	}
+	return false
+}
+
+// run_migrate_templates implements the migrate-templates command
+func run_migrate_templates(ctx context.Context, sc sigsci.Client, api *APIClient, args []string) error {
+	flags := flag.NewFlagSet("migrate-templates", flag.ExitOnError)
+	corp := flags.String("corp", os.Getenv("TF_VAR_NGWAF_CORP"), "corp to migrate")
+	site := flags.String("site", "", "site to migrate, all sites when empty")
+	outputDir := flags.String("out", "migrated", "directory to write migrate.tf to")
+	format := flags.String("format", formatHCL, "output syntax, hcl or json (.tf.json)")
+	flags.Parse(args)
+
+	if err := validFormat(*format); err != nil {
+		return err
+	}
+	snapshot, err := fetch_corp_snapshot(ctx, sc, api, *corp)
+	if err != nil {
+		return err
+	}
+	warnings, err := migrate_templated_rules(snapshot, *site, *outputDir)
+	if err != nil {
+		return err
+	}
+	for _, warning := range warnings {
+		fmt.Println("WARNING:", warning)
+	}
+	if *format == formatJSON {
+		return convert_terraform_files_to_json(*outputDir, "migrate.tf")
+	}
+	return nil
+}
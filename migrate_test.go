@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	sigsci "github.com/signalsciences/go-sigsci"
+)
+
+func TestMigrateTemplatedRules(t *testing.T) {
+	_, sc, api := newFakeAPI(t, "testcorp")
+	snapshot, err := fetch_corp_snapshot(context.Background(), sc, api, "testcorp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range snapshot.Sites {
+		if snapshot.Sites[i].Site.Name != "www" {
+			continue
+		}
+		templates := &snapshot.Sites[i].LegacyTemplatedRules
+		templates.Data = append(templates.Data, ResponseSiteLegacyTemplatedRuleBody{
+			Name: "PW-RESET-ATTEMPT",
+			Detections: []Detection{
+				{ID: "67b8293a4b5c6d7e8f901234", Enabled: true, Fields: []Field{{Name: "path", Value: "/password/*"}}},
+				{ID: "67b8293a4b5c6d7e8f901235", Enabled: false, Fields: []Field{{Name: "path", Value: "/reset"}, {Name: "postParameter", Value: "email"}}},
+				{ID: "67b8293a4b5c6d7e8f901236", Enabled: true, Fields: []Field{{Name: "ja3", Value: "abc"}}},
+			},
+			Alerts: []sigsci.CustomAlert{
+				{ID: "67b8293a4b5c6d7e8f901237", LongName: "Password resets", Interval: 10, Threshold: 20, Enabled: true, Action: "info"},
+				{ID: "67b8293a4b5c6d7e8f901238", LongName: "Password reset flood", Interval: 1, Threshold: 100, Enabled: true, Action: "flagged"},
+				{ID: "67b8293a4b5c6d7e8f901239", LongName: "Password reset metric", Interval: 1, Threshold: 100, Enabled: true, Action: "siteMetricInfo"},
+			},
+		})
+	}
+
+	outputDir := t.TempDir()
+	warnings, err := migrate_templated_rules(snapshot, "www", outputDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertGolden(t, filepath.Join(outputDir, "migrate.tf"), filepath.Join("testcorp", "migrate", "migrate.tf"))
+
+	want := []string{
+		"templated rule LOGINATTEMPT, detection 65f60718293a4b5c6d7e8f90: site www already has an equivalent rule, not migrated",
+		"templated rule CCARD has no detections or alerts, nothing to migrate",
+		`templated rule PW-RESET-ATTEMPT, detection 67b8293a4b5c6d7e8f901235: field postParameter = "email" has no rule condition equivalent`,
+		"templated rule PW-RESET-ATTEMPT, detection 67b8293a4b5c6d7e8f901235: migrated without its untranslatable fields, it matches more requests",
+		`templated rule PW-RESET-ATTEMPT, detection 67b8293a4b5c6d7e8f901236: field ja3 = "abc" has no rule condition equivalent`,
+		"templated rule PW-RESET-ATTEMPT, detection 67b8293a4b5c6d7e8f901236: no translatable fields, not migrated",
+		`templated rule PW-RESET-ATTEMPT, alert 67b8293a4b5c6d7e8f901239 (Password reset metric) has action "siteMetricInfo", which site alerts do not support, not migrated`,
+	}
+	if !slices.Equal(warnings, want) {
+		t.Errorf("got warnings\n%q\nwant\n%q", warnings, want)
+	}
+
+	if _, err := migrate_templated_rules(snapshot, "missing", t.TempDir()); err == nil {
+		t.Error("migrating a missing site succeeded")
+	}
+}
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	sigsci "github.com/signalsciences/go-sigsci"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// tfBlock is the configuration of a resource or of one of its nested blocks,
+// independent of the syntax it is emitted in. Attribute values are string,
+// int, bool, []string, hcl.Traversal, tfVariable or, for expressions only
+// HCL can hold, hclwrite.Tokens.
+type tfBlock struct {
+	Attrs  []tfAttr
+	Blocks []tfNestedBlock
+}
+
+type tfAttr struct {
+	Name  string
+	Value interface{}
+}
+
+type tfNestedBlock struct {
+	Type string
+	Body *tfBlock
+}
+
+func (b *tfBlock) set(name string, value interface{}) {
+	b.Attrs = append(b.Attrs, tfAttr{Name: name, Value: value})
+}
+
+// setOptional sets a string attribute unless value is empty
+func (b *tfBlock) setOptional(name string, value string) {
+	if value != "" {
+		b.set(name, value)
+	}
+}
+
+// setRef sets a string attribute, as a reference when one is known and not
+// at all when value is empty
+func (b *tfBlock) setRef(name string, value string, refs references) {
+	if value == "" {
+		return
+	}
+	if traversal, ok := refs[value]; ok {
+		b.set(name, traversal)
+		return
+	}
+	b.set(name, value)
+}
+
+func (b *tfBlock) block(blockType string) *tfBlock {
+	child := &tfBlock{}
+	b.Blocks = append(b.Blocks, tfNestedBlock{Type: blockType, Body: child})
+	return child
+}
+
+// singleBlocks are nested blocks the provider allows at most once, which
+// CDKTF types as a single object rather than a list
+var singleBlocks = map[string]bool{
+	"rate_limit": true,
+}
+
+func conditions_config(parent *tfBlock, conditions []sigsci.Condition, refs references) {
+	for _, condition := range conditions {
+		block := parent.block("conditions")
+		block.set("type", condition.Type)
+		block.setOptional("group_operator", condition.GroupOperator)
+		block.setOptional("field", condition.Field)
+		block.setOptional("operator", condition.Operator)
+		block.setRef("value", condition.Value, refs)
+		conditions_config(block, condition.Conditions, refs)
+	}
+}
+
+func actions_config(parent *tfBlock, actions []sigsci.Action, refs references) {
+	for _, action := range actions {
+		block := parent.block("actions")
+		block.set("type", action.Type)
+		block.setRef("signal", action.Signal, refs)
+		if action.ResponseCode != 0 {
+			block.set("response_code", action.ResponseCode)
+		}
+		block.setOptional("redirect_url", action.RedirectURL)
+		if action.AllowInteractive {
+			block.set("allow_interactive", true)
+		}
+	}
+}
+
+func rate_limit_config(parent *tfBlock, rateLimit *sigsci.RateLimit) {
+	if rateLimit == nil {
+		return
+	}
+	block := parent.block("rate_limit")
+	block.set("threshold", rateLimit.Threshold)
+	block.set("interval", rateLimit.Interval)
+	block.set("duration", rateLimit.Duration)
+	for _, clientIdentifier := range rateLimit.ClientIdentifiers {
+		identifier := block.block("client_identifiers")
+		identifier.set("type", clientIdentifier.Type)
+		identifier.setOptional("key", clientIdentifier.Key)
+		identifier.setOptional("name", clientIdentifier.Name)
+	}
+}
+
+func site_rule_config(siteShortName string, rule sigsci.CreateSiteRuleBody, refs references) *tfBlock {
+	config := &tfBlock{}
+	config.set("site_short_name", siteShortName)
+	config.set("type", rule.Type)
+	config.set("group_operator", rule.GroupOperator)
+	config.set("enabled", rule.Enabled)
+	config.set("reason", rule.Reason)
+	config.setRef("signal", rule.Signal, refs)
+	config.setOptional("expiration", rule.Expiration)
+	config.setOptional("requestlogging", rule.RequestLogging)
+	conditions_config(config, rule.Conditions, refs)
+	actions_config(config, rule.Actions, refs)
+	rate_limit_config(config, rule.RateLimit)
+	return config
+}
+
+func corp_rule_config(rule sigsci.CreateCorpRuleBody, refs references) *tfBlock {
+	config := &tfBlock{}
+	config.set("site_short_names", append([]string{}, rule.SiteNames...))
+	config.set("type", rule.Type)
+	config.set("corp_scope", rule.CorpScope)
+	config.set("group_operator", rule.GroupOperator)
+	config.set("enabled", rule.Enabled)
+	config.set("reason", rule.Reason)
+	config.setRef("signal", rule.Signal, refs)
+	config.setOptional("expiration", rule.Expiration)
+	config.setOptional("requestlogging", rule.RequestLogging)
+	conditions_config(config, rule.Conditions, refs)
+	actions_config(config, rule.Actions, refs)
+	return config
+}
+
+func list_config(siteShortName string, list sigsci.CreateListBody) *tfBlock {
+	config := &tfBlock{}
+	config.setOptional("site_short_name", siteShortName)
+	config.set("name", list.Name)
+	config.set("type", list.Type)
+	config.set("description", list.Description)
+	config.set("entries", append([]string{}, list.Entries...))
+	return config
+}
+
+func site_signal_tag_config(siteShortName string, tag sigsci.CreateSignalTagBody) *tfBlock {
+	config := &tfBlock{}
+	config.set("site_short_name", siteShortName)
+	config.set("name", tag.ShortName)
+	config.set("description", tag.Description)
+	return config
+}
+
+func corp_signal_tag_config(tag sigsci.CreateSignalTagBody) *tfBlock {
+	config := &tfBlock{}
+	config.set("short_name", tag.ShortName)
+	config.set("description", tag.Description)
+	return config
+}
+
+func site_alert_config(siteShortName string, alert sigsci.CustomAlert, refs references) *tfBlock {
+	config := &tfBlock{}
+	config.set("site_short_name", siteShortName)
+	config.setRef("tag_name", alert.TagName, refs)
+	config.set("long_name", alert.LongName)
+	config.set("interval", alert.Interval)
+	config.set("threshold", alert.Threshold)
+	config.set("enabled", alert.Enabled)
+	config.set("action", alert.Action)
+	config.set("skip_notifications", alert.SkipNotifications)
+	if alert.BlockDurationSeconds != 0 {
+		config.set("block_duration_seconds", alert.BlockDurationSeconds)
+	}
+	return config
+}
+
+func site_agent_alert_config(siteShortName string, alert sigsci.CustomAlert) *tfBlock {
+	config := &tfBlock{}
+	config.set("site_short_name", siteShortName)
+	config.set("tag_name", alert.TagName)
+	config.set("long_name", alert.LongName)
+	config.set("interval", alert.Interval)
+	config.set("threshold", alert.Threshold)
+	config.set("enabled", alert.Enabled)
+	config.set("action", alert.Action)
+	config.set("skip_notifications", alert.SkipNotifications)
+	return config
+}
+
+func site_config(site sigsci.Site) *tfBlock {
+	config := &tfBlock{}
+	config.set("short_name", site.Name)
+	config.set("display_name", site.DisplayName)
+	config.set("agent_level", site.AgentLevel)
+	config.setOptional("agent_anon_mode", site.AgentAnonMode)
+	config.set("block_http_code", site.BlockHTTPCode)
+	config.set("block_duration_seconds", site.BlockDurationSeconds)
+	config.setOptional("block_redirect_url", site.BlockRedirectURL)
+	for _, rule := range site.ClientIPRules {
+		config.block("client_ip_rules").set("header", rule.Header)
+	}
+	return config
+}
+
+func site_integration_config(siteShortName string, integration sigsci.Integration) *tfBlock {
+	config := &tfBlock{}
+	config.set("site_short_name", siteShortName)
+	config.set("type", integration.Type)
+	config.set("url", integration.URL)
+	config.set("events", append([]string{}, integration.Events...))
+	return config
+}
+
+func site_header_link_config(siteShortName string, link sigsci.HeaderLink) *tfBlock {
+	config := &tfBlock{}
+	config.set("site_short_name", siteShortName)
+	config.set("type", link.Type)
+	config.set("name", link.Name)
+	config.set("link_name", link.LinkName)
+	config.set("link", link.Link)
+	return config
+}
+
+func site_templated_rule_config(siteShortName string, rule ResponseSiteLegacyTemplatedRuleBody) *tfBlock {
+	config := &tfBlock{}
+	config.set("site_short_name", siteShortName)
+	config.set("name", rule.Name)
+	for _, detection := range rule.Detections {
+		block := config.block("detections")
+		block.set("enabled", detection.Enabled)
+		for _, field := range detection.Fields {
+			fieldBlock := block.block("fields")
+			fieldBlock.set("name", field.Name)
+			fieldBlock.set("value", field.Value)
+		}
+	}
+	for _, alert := range rule.Alerts {
+		block := config.block("alerts")
+		block.set("long_name", alert.LongName)
+		block.set("interval", alert.Interval)
+		block.set("threshold", alert.Threshold)
+		block.set("skip_notifications", alert.SkipNotifications)
+		block.set("enabled", alert.Enabled)
+		block.set("action", alert.Action)
+	}
+	return config
+}
+
+// target_config builds the resource configuration of an import target from
+// the API object it was discovered from
+func target_config(target ImportTarget) (*tfBlock, error) {
+	switch object := target.Object.(type) {
+	case sigsci.ResponseCorpRuleBody:
+		return corp_rule_config(object.CreateCorpRuleBody, nil), nil
+	case sigsci.ResponseSiteRuleBody:
+		return site_rule_config(target.Site, object.CreateSiteRuleBody, nil), nil
+	case sigsci.ResponseListBody:
+		return list_config(target.Site, object.CreateListBody), nil
+	case sigsci.ResponseSignalTagBody:
+		if target.Site == "" {
+			return corp_signal_tag_config(object.CreateSignalTagBody), nil
+		}
+		return site_signal_tag_config(target.Site, object.CreateSignalTagBody), nil
+	case sigsci.CustomAlert:
+		if target.ResourceType == "sigsci_site_agent_alert" {
+			return site_agent_alert_config(target.Site, object), nil
+		}
+		return site_alert_config(target.Site, object, nil), nil
+	case sigsci.Site:
+		return site_config(object), nil
+	case sigsci.Integration:
+		return site_integration_config(target.Site, object), nil
+	case sigsci.HeaderLink:
+		return site_header_link_config(target.Site, object), nil
+	case ResponseSiteLegacyTemplatedRuleBody:
+		return site_templated_rule_config(target.Site, object), nil
+	}
+	return nil, fmt.Errorf("no configuration for %s of type %T", target.Address(), target.Object)
+}
+
+// render_resource appends a resource block with config to file
+func render_resource(file *hclwrite.File, resourceType string, name string, config *tfBlock) {
+	render_block_body(file.Body().AppendNewBlock("resource", []string{resourceType, name}).Body(), config)
+	file.Body().AppendNewline()
+}
+
+func render_block_body(body *hclwrite.Body, config *tfBlock) {
+	for _, attr := range config.Attrs {
+		switch value := attr.Value.(type) {
+		case string:
+			body.SetAttributeValue(attr.Name, cty.StringVal(value))
+		case int:
+			body.SetAttributeValue(attr.Name, cty.NumberIntVal(int64(value)))
+		case bool:
+			body.SetAttributeValue(attr.Name, cty.BoolVal(value))
+		case []string:
+			body.SetAttributeValue(attr.Name, stringListVal(value))
+		case hcl.Traversal:
+			body.SetAttributeTraversal(attr.Name, value)
+		case hclwrite.Tokens:
+			body.SetAttributeRaw(attr.Name, value)
+		case tfVariable:
+			body.SetAttributeTraversal(attr.Name, variableTraversal(value))
+		}
+	}
+	for _, block := range config.Blocks {
+		render_block_body(body.AppendNewBlock(block.Type, nil).Body(), block.Body)
+	}
+}
@@ -0,0 +1,368 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	sigsci "github.com/signalsciences/go-sigsci"
+)
+
+// apiRequest is the API call creating or updating the object of a resource
+type apiRequest struct {
+	Address string      `json:"address"`
+	Method  string      `json:"method"`
+	Path    string      `json:"path"`
+	Body    interface{} `json:"body"`
+}
+
+// apiResourceOrder ranks resource types so objects are created after the
+// objects they reference
+var apiResourceOrder = map[string]int{
+	"sigsci_site":                0,
+	"sigsci_corp_signal_tag":     1,
+	"sigsci_site_signal_tag":     1,
+	"sigsci_corp_list":           2,
+	"sigsci_site_list":           2,
+	"sigsci_corp_rule":           3,
+	"sigsci_site_rule":           3,
+	"sigsci_site_alert":          4,
+	"sigsci_site_agent_alert":    4,
+	"sigsci_site_templated_rule": 4,
+	"sigsci_site_integration":    5,
+	"sigsci_site_header_link":    5,
+}
+
+// apiCollections are the API collections of the decodable resource types
+var apiCollections = map[string]string{
+	"sigsci_site":                "sites",
+	"sigsci_site_templated_rule": "configuredtemplates",
+	"sigsci_corp_rule":           "rules",
+	"sigsci_site_rule":           "rules",
+	"sigsci_corp_list":           "lists",
+	"sigsci_site_list":           "lists",
+	"sigsci_corp_signal_tag":     "tags",
+	"sigsci_site_signal_tag":     "tags",
+	"sigsci_site_alert":          "alerts",
+	"sigsci_site_agent_alert":    "alerts",
+	"sigsci_site_integration":    "integrations",
+	"sigsci_site_header_link":    "headerLinks",
+}
+
+// listEntriesFunc returns the current entries of the existing list with
+// the given ID
+type listEntriesFunc func(resource apiResource, id string) ([]string, error)
+
+// templatedRuleFunc returns the current state of the existing templated
+// rule with the given name
+type templatedRuleFunc func(resource apiResource, name string) (ResponseSiteLegacyTemplatedRuleBody, error)
+
+// collection_path is the API path of the collection holding the object of
+// resource
+func collection_path(corp string, resource apiResource) (string, error) {
+	collection := apiCollections[resource.Type]
+	if strings.HasPrefix(resource.Type, "sigsci_corp_") || resource.Type == "sigsci_site" {
+		return fmt.Sprintf("/v0/corps/%s/%s", corp, collection), nil
+	}
+	if resource.Site == "" {
+		return "", fmt.Errorf("%s has no site_short_name", resource.Address)
+	}
+	return fmt.Sprintf("/v0/corps/%s/sites/%s/%s", corp, resource.Site, collection), nil
+}
+
+// object_id is the API ID in the import ID of a resource, without the
+// site prefix of site objects
+func object_id(resource apiResource) string {
+	if resource.Site != "" {
+		return strings.TrimPrefix(resource.ImportID, resource.Site+":")
+	}
+	return resource.ImportID
+}
+
+// entriesDiff returns the entries to add to and delete from current to get
+// desired
+func entriesDiff(current []string, desired []string) ([]string, []string) {
+	have := map[string]bool{}
+	for _, entry := range current {
+		have[entry] = true
+	}
+	want := map[string]bool{}
+	var additions, deletions []string
+	for _, entry := range uniqueSortedEntries(desired) {
+		want[entry] = true
+		if !have[entry] {
+			additions = append(additions, entry)
+		}
+	}
+	for _, entry := range uniqueSortedEntries(current) {
+		if !want[entry] {
+			deletions = append(deletions, entry)
+		}
+	}
+	return additions, deletions
+}
+
+// templated_rule_changes returns the configured template update turning
+// current into desired. Detections are matched by their fields and alerts
+// by their long name; matches that differ are updated, the rest of current
+// is deleted and the rest of desired added.
+func templated_rule_changes(current ResponseSiteLegacyTemplatedRuleBody, desired ResponseSiteLegacyTemplatedRuleBody) sigsci.SiteTemplateRuleBody {
+	changes := sigsci.SiteTemplateRuleBody{
+		DetectionAdds:    []sigsci.Detection{},
+		DetectionUpdates: []sigsci.Detection{},
+		DetectionDeletes: []sigsci.Detection{},
+		AlertAdds:        []sigsci.Alert{},
+		AlertUpdates:     []sigsci.Alert{},
+		AlertDeletes:     []sigsci.Alert{},
+	}
+
+	matched := map[int]bool{}
+	for _, detection := range desired.Detections {
+		body := detectionUpdateBody(desired.Name, detection)
+		index := -1
+		for i, existing := range current.Detections {
+			if !matched[i] && slices.Equal(existing.Fields, detection.Fields) {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			changes.DetectionAdds = append(changes.DetectionAdds, sigsci.Detection{DetectionUpdateBody: body})
+			continue
+		}
+		matched[index] = true
+		if current.Detections[index].Enabled != detection.Enabled {
+			body.ID = current.Detections[index].ID
+			changes.DetectionUpdates = append(changes.DetectionUpdates, sigsci.Detection{DetectionUpdateBody: body})
+		}
+	}
+	for i, existing := range current.Detections {
+		if !matched[i] {
+			changes.DetectionDeletes = append(changes.DetectionDeletes, sigsci.Detection{DetectionUpdateBody: sigsci.DetectionUpdateBody{ID: existing.ID, Name: current.Name}})
+		}
+	}
+
+	matched = map[int]bool{}
+	for _, alert := range desired.Alerts {
+		body := alertUpdateBody(alert)
+		index := -1
+		for i, existing := range current.Alerts {
+			if !matched[i] && existing.LongName == alert.LongName {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			changes.AlertAdds = append(changes.AlertAdds, sigsci.Alert{AlertUpdateBody: body})
+			continue
+		}
+		matched[index] = true
+		if alertUpdateBody(current.Alerts[index]) != body {
+			changes.AlertUpdates = append(changes.AlertUpdates, sigsci.Alert{AlertUpdateBody: body, ID: current.Alerts[index].ID})
+		}
+	}
+	for i, existing := range current.Alerts {
+		if !matched[i] {
+			changes.AlertDeletes = append(changes.AlertDeletes, sigsci.Alert{AlertUpdateBody: alertUpdateBody(existing), ID: existing.ID})
+		}
+	}
+	return changes
+}
+
+func detectionUpdateBody(template string, detection Detection) sigsci.DetectionUpdateBody {
+	body := sigsci.DetectionUpdateBody{Name: template, Enabled: detection.Enabled, Fields: []sigsci.ConfiguredDetectionField{}}
+	for _, field := range detection.Fields {
+		body.Fields = append(body.Fields, sigsci.ConfiguredDetectionField{Name: field.Name, Value: field.Value})
+	}
+	return body
+}
+
+func alertUpdateBody(alert sigsci.CustomAlert) sigsci.AlertUpdateBody {
+	return sigsci.AlertUpdateBody{
+		LongName:             alert.LongName,
+		Interval:             alert.Interval,
+		Threshold:            alert.Threshold,
+		SkipNotifications:    alert.SkipNotifications,
+		Enabled:              alert.Enabled,
+		Action:               alert.Action,
+		BlockDurationSeconds: alert.BlockDurationSeconds,
+	}
+}
+
+// api_requests converts decoded resources to the API calls applying them,
+// in dependency order. Resources with an import ID update their object,
+// the others create one. Updating a list sends the entries to add and
+// delete, computed from its current entries, and updating a templated rule
+// the detections and alerts to change, computed from its current state.
+// It returns the requests and warnings about resources that cannot be
+// applied.
+func api_requests(corp string, resources []apiResource, currentEntries listEntriesFunc, currentTemplatedRule templatedRuleFunc) ([]apiRequest, []string, error) {
+	sorted := append([]apiResource{}, resources...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return apiResourceOrder[sorted[i].Type] < apiResourceOrder[sorted[j].Type]
+	})
+
+	var requests []apiRequest
+	var warnings []string
+	for _, resource := range sorted {
+		if resource.Err != nil {
+			return nil, nil, resource.Err
+		}
+		path, err := collection_path(corp, resource)
+		if err != nil {
+			return nil, nil, err
+		}
+		if rule, ok := resource.Body.(ResponseSiteLegacyTemplatedRuleBody); ok {
+			// Configured templates are created and updated alike, by
+			// posting their changes
+			current := ResponseSiteLegacyTemplatedRuleBody{Name: rule.Name}
+			if resource.ImportID != "" {
+				if current, err = currentTemplatedRule(resource, rule.Name); err != nil {
+					return nil, nil, fmt.Errorf("error fetching %s: %v", resource.Address, err)
+				}
+			}
+			requests = append(requests, apiRequest{Address: resource.Address, Method: "POST", Path: path + "/" + rule.Name, Body: templated_rule_changes(current, rule)})
+			continue
+		}
+		if resource.ImportID == "" {
+			requests = append(requests, apiRequest{Address: resource.Address, Method: "POST", Path: path, Body: resource.Body})
+			continue
+		}
+
+		id := object_id(resource)
+		request := apiRequest{Address: resource.Address, Method: "PATCH", Path: path + "/" + id, Body: resource.Body}
+		switch body := resource.Body.(type) {
+		case sigsci.CreateCorpRuleBody, sigsci.CreateSiteRuleBody:
+			request.Method = "PUT"
+		case sigsci.CreateListBody:
+			current, err := currentEntries(resource, id)
+			if err != nil {
+				return nil, nil, fmt.Errorf("error fetching entries of %s: %v", resource.Address, err)
+			}
+			additions, deletions := entriesDiff(current, body.Entries)
+			request.Body = sigsci.UpdateListBody{
+				Description: body.Description,
+				Entries:     sigsci.Entries{Additions: additions, Deletions: deletions},
+			}
+		case sigsci.CreateSignalTagBody:
+			request.Body = sigsci.UpdateSignalTagBody{Description: body.Description}
+		case sigsci.CreateSiteBody:
+			request.Body = sigsci.UpdateSiteBody{
+				DisplayName:          body.DisplayName,
+				AgentLevel:           body.AgentLevel,
+				AgentAnonMode:        body.AgentAnonMode,
+				BlockHTTPCode:        body.BlockHTTPCode,
+				BlockRedirectURL:     body.BlockRedirectURL,
+				BlockDurationSeconds: body.BlockDurationSeconds,
+				ClientIPRules:        body.ClientIPRules,
+			}
+		case sigsci.IntegrationBody:
+			request.Body = sigsci.UpdateIntegrationBody{URL: body.URL, Events: body.Events}
+		case sigsci.HeaderLinkBody:
+			warnings = append(warnings, fmt.Sprintf("%s: header links cannot be updated, delete and recreate %s to change it", resource.Address, id))
+			continue
+		}
+		requests = append(requests, request)
+	}
+	return requests, warnings, nil
+}
+
+// apply_requests sends requests in order, stopping at the first failure
+func apply_requests(ctx context.Context, api *APIClient, requests []apiRequest) error {
+	for _, request := range requests {
+		body, err := json.Marshal(request.Body)
+		if err != nil {
+			return err
+		}
+		if _, err := api.Do(ctx, request.Method, request.Path, string(body)); err != nil {
+			return fmt.Errorf("error applying %s: %v", request.Address, err)
+		}
+		fmt.Println(request.Method, request.Path)
+	}
+	return nil
+}
+
+// sigsci_templated_rule reads the current state of templated rules from the
+// API
+func sigsci_templated_rule(ctx context.Context, api *APIClient, corp string) templatedRuleFunc {
+	return func(resource apiResource, name string) (ResponseSiteLegacyTemplatedRuleBody, error) {
+		rules, err := get_active_legacy_templated_rules(ctx, api, corp, resource.Site)
+		if err != nil {
+			return ResponseSiteLegacyTemplatedRuleBody{}, err
+		}
+		for _, rule := range rules.Data {
+			if rule.Name == name {
+				return rule, nil
+			}
+		}
+		return ResponseSiteLegacyTemplatedRuleBody{}, fmt.Errorf("site %s has no templated rule %s", resource.Site, name)
+	}
+}
+
+// sigsci_list_entries reads the current entries of lists from the API
+func sigsci_list_entries(sc sigsci.Client, corp string) listEntriesFunc {
+	return func(resource apiResource, id string) ([]string, error) {
+		var list sigsci.ResponseListBody
+		var err error
+		if resource.Type == "sigsci_corp_list" {
+			list, err = sc.GetCorpListByID(corp, id)
+		} else {
+			list, err = sc.GetSiteListByID(corp, resource.Site, id)
+		}
+		return list.Entries, err
+	}
+}
+
+func run_payloads(ctx context.Context, sc sigsci.Client, api *APIClient, args []string) error {
+	flags := flag.NewFlagSet("payloads", flag.ExitOnError)
+	corp := flags.String("corp", os.Getenv("TF_VAR_NGWAF_CORP"), "corp the configuration belongs to")
+	out := flags.String("out", "", "file to write the payloads to, defaults to stdout")
+	apply := flags.Bool("apply", false, "send the requests to the API, without Terraform")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: payloads [-corp corp] [-out payloads.json] [-apply] file.tf ...")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return fmt.Errorf("payloads needs configuration files")
+	}
+
+	files, err := read_config_files(flags.Args())
+	if err != nil {
+		return err
+	}
+	resources, err := decode_resources(files, filepath.Dir(flags.Arg(0)), nil)
+	if err != nil {
+		return err
+	}
+	requests, warnings, err := api_requests(*corp, resources, sigsci_list_entries(sc, *corp), sigsci_templated_rule(ctx, api, *corp))
+	if err != nil {
+		return err
+	}
+	for _, warning := range warnings {
+		fmt.Fprintln(os.Stderr, "WARNING:", warning)
+	}
+
+	content, err := json.MarshalIndent(requests, "", "  ")
+	if err != nil {
+		return err
+	}
+	content = append(content, '\n')
+	if *out == "" {
+		os.Stdout.Write(content)
+	} else if err := os.WriteFile(*out, content, 0666); err != nil {
+		return fmt.Errorf("error writing %s: %v", *out, err)
+	}
+
+	if *apply {
+		return apply_requests(ctx, api, requests)
+	}
+	return nil
+}
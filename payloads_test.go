@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+
+	sigsci "github.com/signalsciences/go-sigsci"
+)
+
+func TestPayloadsGolden(t *testing.T) {
+	path := filepath.Join("testdata", "golden", "promote", "promote.tf")
+	files, err := read_config_files([]string{path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resources, err := decode_resources(files, filepath.Dir(path), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	requests, warnings, err := api_requests("prodcorp", resources, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) > 0 {
+		t.Errorf("unexpected warnings %v", warnings)
+	}
+
+	content, err := json.MarshalIndent(requests, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(t.TempDir(), "payloads.json")
+	if err := os.WriteFile(out, append(content, '\n'), 0644); err != nil {
+		t.Fatal(err)
+	}
+	assertGolden(t, out, filepath.Join("payloads", "promote.json"))
+}
+
+func TestPayloadsApplyUpdates(t *testing.T) {
+	server, sc, api := newFakeAPI(t, "testcorp")
+	outputDir := t.TempDir()
+
+	opts := RunOptions{Format: formatHCL, Output: outputTerraform, Compact: true, ListFiles: listFilesTxt}
+	if err := terraformify_corp(context.Background(), sc, api, "testcorp", outputDir, opts, ""); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TF_VAR_site_integration_"+sanitizeTfId("62c3d4e5f60718293a4b5c6d")+"_url", "https://hooks.slack.com/services/T000/B000/XXXXSECRET")
+	listFile := filepath.Join(outputDir, "lists", "www"+sanitizeTfId("site.office-ips")+".txt")
+	if err := os.WriteFile(listFile, []byte("192.0.2.11\n192.0.2.12\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(outputDir, "import.tf")
+	files, err := read_config_files([]string{path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resources, err := decode_resources(files, outputDir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	requests, warnings, err := api_requests("testcorp", resources, sigsci_list_entries(sc, "testcorp"), sigsci_templated_rule(context.Background(), api, "testcorp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var listUpdate, siteUpdate interface{}
+	for _, request := range requests {
+		if changes, ok := request.Body.(sigsci.SiteTemplateRuleBody); ok {
+			// Unchanged templated rules post no changes
+			if len(changes.DetectionAdds)+len(changes.DetectionUpdates)+len(changes.DetectionDeletes)+
+				len(changes.AlertAdds)+len(changes.AlertUpdates)+len(changes.AlertDeletes) > 0 {
+				t.Errorf("%s changes %+v", request.Address, changes)
+			}
+			continue
+		}
+		if request.Method == "POST" {
+			t.Errorf("%s creates an object, every resource is imported", request.Address)
+		}
+		switch request.Path {
+		case "/v0/corps/testcorp/sites/www/lists/site.office-ips":
+			listUpdate = request.Body
+		case "/v0/corps/testcorp/sites/www":
+			siteUpdate = request.Body
+		}
+	}
+	if site, ok := siteUpdate.(sigsci.UpdateSiteBody); !ok || site.AgentLevel == "" || site.BlockHTTPCode == 0 {
+		t.Errorf("site update = %+v", siteUpdate)
+	}
+	want := sigsci.UpdateListBody{
+		Description: "Office egress addresses",
+		Entries:     sigsci.Entries{Additions: []string{"192.0.2.12"}, Deletions: []string{"192.0.2.10"}},
+	}
+	if !reflect.DeepEqual(listUpdate, want) {
+		t.Errorf("list update = %+v, want %+v", listUpdate, want)
+	}
+	if len(warnings) != 1 {
+		t.Errorf("expected a warning for the header link, got %v", warnings)
+	}
+
+	if err := apply_requests(context.Background(), api, requests); err != nil {
+		t.Fatal(err)
+	}
+	entries := server.Collection("corps/testcorp/sites/www/lists")[0]["entries"]
+	if !reflect.DeepEqual(entries, []interface{}{"192.0.2.11", "192.0.2.12"}) {
+		t.Errorf("entries after apply = %v", entries)
+	}
+
+	problems, _, err := verify_corp(context.Background(), sc, api, "testcorp", []string{path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) > 0 {
+		t.Errorf("applied configuration differs from the API:\n%v", problems)
+	}
+}
+
+func TestTemplatedRuleChanges(t *testing.T) {
+	current := ResponseSiteLegacyTemplatedRuleBody{
+		Name: "LOGINATTEMPT",
+		Detections: []Detection{
+			{ID: "d1", Enabled: true, Fields: []Field{{Name: "path", Value: "/login"}}},
+			{ID: "d2", Enabled: true, Fields: []Field{{Name: "path", Value: "/signin"}}},
+		},
+		Alerts: []sigsci.CustomAlert{
+			{ID: "a1", LongName: "Logins", Interval: 1, Threshold: 10, Enabled: true, Action: "info"},
+			{ID: "a2", LongName: "Old", Interval: 10, Threshold: 10, Enabled: true, Action: "info"},
+		},
+	}
+	desired := ResponseSiteLegacyTemplatedRuleBody{
+		Name: "LOGINATTEMPT",
+		Detections: []Detection{
+			{Enabled: false, Fields: []Field{{Name: "path", Value: "/login"}}},
+			{Enabled: true, Fields: []Field{{Name: "path", Value: "/auth/*"}}},
+		},
+		Alerts: []sigsci.CustomAlert{
+			{LongName: "Logins", Interval: 1, Threshold: 20, Enabled: true, Action: "info"},
+		},
+	}
+
+	changes := templated_rule_changes(current, desired)
+	ids := func(detections []sigsci.Detection) []string {
+		var ids []string
+		for _, detection := range detections {
+			ids = append(ids, detection.ID)
+		}
+		return ids
+	}
+	if len(changes.DetectionAdds) != 1 || changes.DetectionAdds[0].Fields[0].Value != "/auth/*" {
+		t.Errorf("detection adds %+v", changes.DetectionAdds)
+	}
+	if got := ids(changes.DetectionUpdates); !slices.Equal(got, []string{"d1"}) || changes.DetectionUpdates[0].Enabled {
+		t.Errorf("detection updates %+v", changes.DetectionUpdates)
+	}
+	if got := ids(changes.DetectionDeletes); !slices.Equal(got, []string{"d2"}) {
+		t.Errorf("detection deletes %v", got)
+	}
+	if len(changes.AlertAdds) != 0 || len(changes.AlertUpdates) != 1 || changes.AlertUpdates[0].ID != "a1" || changes.AlertUpdates[0].Threshold != 20 {
+		t.Errorf("alert adds %+v, updates %+v", changes.AlertAdds, changes.AlertUpdates)
+	}
+	if len(changes.AlertDeletes) != 1 || changes.AlertDeletes[0].ID != "a2" {
+		t.Errorf("alert deletes %+v", changes.AlertDeletes)
+	}
+}
+
+func TestEntriesDiff(t *testing.T) {
+	additions, deletions := entriesDiff([]string{"a", "b", "c"}, []string{"d", "b", "a", "d"})
+	if !slices.Equal(additions, []string{"d"}) || !slices.Equal(deletions, []string{"c"}) {
+		t.Errorf("additions %v, deletions %v", additions, deletions)
+	}
+}
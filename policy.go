@@ -0,0 +1,367 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/tryfunc"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+)
+
+// policyResource is a resource of the policy model: the configuration the
+// tool renders for an object, as a cty object
+type policyResource struct {
+	Type    string
+	Address string
+	// Site is the owning site, empty for corp resources
+	Site string
+	// Attributes are the rendered attributes, with id, the API ID, and
+	// nested blocks as lists of objects named after the block type
+	Attributes cty.Value
+}
+
+// policyModel is what policies are evaluated against: every resource of a
+// corp and the lists resources can reference
+type policyModel struct {
+	Corp      string
+	Resources []policyResource
+	// Lists are the lists by ID, per site; the corp lists are under ""
+	Lists map[string]map[string]cty.Value
+}
+
+// policyViolation is a resource failing a policy
+type policyViolation struct {
+	Policy  string
+	Address string
+	Message string
+}
+
+// policyEngine evaluates the policies of one policy language. Languages
+// plug in through policyLoaders.
+type policyEngine interface {
+	evaluate(model policyModel, now time.Time) ([]policyViolation, error)
+}
+
+// policyLoaders load a policy file into an engine, by file extension
+var policyLoaders = map[string]func(file configFile) (policyEngine, error){
+	".hcl": load_hcl_policies,
+}
+
+// policy_model converts imports to the policy model
+func policy_model(corp string, imports *ImportSet) (policyModel, error) {
+	model := policyModel{Corp: corp, Lists: map[string]map[string]cty.Value{}}
+	for _, target := range imports.Targets {
+		config, err := target_config(target)
+		if err != nil {
+			return model, err
+		}
+		id := strings.TrimPrefix(target.ID, target.Site+":")
+		attributes := policy_value(config, map[string]cty.Value{"id": cty.StringVal(id)})
+		model.Resources = append(model.Resources, policyResource{Type: target.ResourceType, Address: target.Address(), Site: target.Site, Attributes: attributes})
+		if target.ResourceType == "sigsci_corp_list" || target.ResourceType == "sigsci_site_list" {
+			if model.Lists[target.Site] == nil {
+				model.Lists[target.Site] = map[string]cty.Value{}
+			}
+			model.Lists[target.Site][id] = attributes
+		}
+	}
+	return model, nil
+}
+
+// policy_value converts a configuration block to a cty object, starting
+// from extra
+func policy_value(config *tfBlock, extra map[string]cty.Value) cty.Value {
+	values := map[string]cty.Value{}
+	for name, value := range extra {
+		values[name] = value
+	}
+	for _, attr := range config.Attrs {
+		switch value := attr.Value.(type) {
+		case string:
+			values[attr.Name] = cty.StringVal(value)
+		case int:
+			values[attr.Name] = cty.NumberIntVal(int64(value))
+		case bool:
+			values[attr.Name] = cty.BoolVal(value)
+		case []string:
+			values[attr.Name] = stringListVal(value)
+		case tfVariable:
+			values[attr.Name] = cty.UnknownVal(cty.String)
+		case hcl.Traversal:
+			values[attr.Name] = cty.StringVal(string(hclwrite.TokensForTraversal(value).Bytes()))
+		case hclwrite.Tokens:
+			values[attr.Name] = cty.StringVal(string(value.Bytes()))
+		}
+	}
+	blocks := map[string][]cty.Value{}
+	var order []string
+	for _, block := range config.Blocks {
+		if _, ok := blocks[block.Type]; !ok {
+			order = append(order, block.Type)
+		}
+		blocks[block.Type] = append(blocks[block.Type], policy_value(block.Body, nil))
+	}
+	for _, blockType := range order {
+		values[blockType] = cty.TupleVal(blocks[blockType])
+	}
+	return cty.ObjectVal(values)
+}
+
+// hclPolicy is a policy block of an HCL policy file
+type hclPolicy struct {
+	Name        string
+	Description string
+	// ResourceType selects the resources checked, "*" for all
+	ResourceType string
+	Where        hcl.Expression
+	Condition    hcl.Expression
+	Message      hcl.Expression
+}
+
+// hclPolicies are the policies of an HCL policy file, evaluated with the
+// expression syntax and functions of Terraform
+type hclPolicies []hclPolicy
+
+var hclPolicySchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{{Type: "policy", LabelNames: []string{"name"}}},
+}
+
+var hclPolicyBodySchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "description"},
+		{Name: "resource", Required: true},
+		{Name: "where"},
+		{Name: "condition", Required: true},
+		{Name: "message"},
+	},
+}
+
+// load_hcl_policies parses the policy blocks of file
+func load_hcl_policies(file configFile) (policyEngine, error) {
+	syntax, diags := hclsyntax.ParseConfig(file.Src, file.Path, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("error parsing %s: %v", file.Path, diags)
+	}
+	content, diags := syntax.Body.Content(hclPolicySchema)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("error reading %s: %v", file.Path, diags)
+	}
+
+	var policies hclPolicies
+	for _, block := range content.Blocks {
+		body, diags := block.Body.Content(hclPolicyBodySchema)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("error reading %s: %v", file.Path, diags)
+		}
+		policy := hclPolicy{Name: block.Labels[0], Condition: body.Attributes["condition"].Expr}
+		for name, target := range map[string]*string{"resource": &policy.ResourceType, "description": &policy.Description} {
+			attr, ok := body.Attributes[name]
+			if !ok {
+				continue
+			}
+			value, diags := attr.Expr.Value(nil)
+			if diags.HasErrors() || value.Type() != cty.String {
+				return nil, fmt.Errorf("%s: %s of policy %s must be a string", attr.Range, name, policy.Name)
+			}
+			*target = value.AsString()
+		}
+		if attr, ok := body.Attributes["where"]; ok {
+			policy.Where = attr.Expr
+		}
+		if attr, ok := body.Attributes["message"]; ok {
+			policy.Message = attr.Expr
+		}
+		policies = append(policies, policy)
+	}
+	return policies, nil
+}
+
+// policyFunctions are the functions policies can call
+func policyFunctions() map[string]function.Function {
+	return map[string]function.Function{
+		"can":             tryfunc.CanFunc,
+		"contains":        stdlib.ContainsFunc,
+		"distinct":        stdlib.DistinctFunc,
+		"flatten":         stdlib.FlattenFunc,
+		"join":            stdlib.JoinFunc,
+		"keys":            stdlib.KeysFunc,
+		"length":          stdlib.LengthFunc,
+		"lookup":          stdlib.LookupFunc,
+		"lower":           stdlib.LowerFunc,
+		"regex":           stdlib.RegexFunc,
+		"regexall":        stdlib.RegexAllFunc,
+		"setintersection": stdlib.SetIntersectionFunc,
+		"setunion":        stdlib.SetUnionFunc,
+		"split":           stdlib.SplitFunc,
+		"timeadd":         stdlib.TimeAddFunc,
+		"trimspace":       stdlib.TrimSpaceFunc,
+		"try":             tryfunc.TryFunc,
+		"upper":           stdlib.UpperFunc,
+	}
+}
+
+// evaluate checks every resource of the policies' types. A resource
+// violates a policy when where is true, or unset, and condition is false.
+// Expressions see the resource as self, its address as address, the lists
+// in its scope by ID as lists, the corp as corp and the time of the run,
+// RFC 3339, as now.
+func (policies hclPolicies) evaluate(model policyModel, now time.Time) ([]policyViolation, error) {
+	var violations []policyViolation
+	for _, policy := range policies {
+		for _, resource := range model.Resources {
+			if policy.ResourceType != "*" && policy.ResourceType != resource.Type {
+				continue
+			}
+			lists := map[string]cty.Value{}
+			for _, scope := range []string{"", resource.Site} {
+				for id, list := range model.Lists[scope] {
+					lists[id] = list
+				}
+			}
+			ctx := &hcl.EvalContext{
+				Variables: map[string]cty.Value{
+					"self":    resource.Attributes,
+					"address": cty.StringVal(resource.Address),
+					"lists":   cty.ObjectVal(lists),
+					"corp":    cty.StringVal(model.Corp),
+					"now":     cty.StringVal(now.UTC().Format(time.RFC3339)),
+				},
+				Functions: policyFunctions(),
+			}
+
+			if policy.Where != nil {
+				applies, err := policy_bool(policy.Where, ctx)
+				if err != nil {
+					return nil, fmt.Errorf("policy %s, where of %s: %v", policy.Name, resource.Address, err)
+				}
+				if !applies {
+					continue
+				}
+			}
+			passes, err := policy_bool(policy.Condition, ctx)
+			if err != nil {
+				return nil, fmt.Errorf("policy %s, condition of %s: %v", policy.Name, resource.Address, err)
+			}
+			if passes {
+				continue
+			}
+
+			message := policy.Description
+			if policy.Message != nil {
+				value, diags := policy.Message.Value(ctx)
+				if diags.HasErrors() || value.Type() != cty.String || !value.IsKnown() {
+					return nil, fmt.Errorf("policy %s, message of %s: %v", policy.Name, resource.Address, diags)
+				}
+				message = value.AsString()
+			}
+			violations = append(violations, policyViolation{Policy: policy.Name, Address: resource.Address, Message: message})
+		}
+	}
+	return violations, nil
+}
+
+// policy_bool evaluates a boolean policy expression. Unknown values, from
+// secrets, count as true.
+func policy_bool(expr hcl.Expression, ctx *hcl.EvalContext) (bool, error) {
+	value, diags := expr.Value(ctx)
+	if diags.HasErrors() {
+		return false, diags
+	}
+	if value.Type() != cty.Bool || value.IsNull() {
+		return false, fmt.Errorf("%s: expected a bool, got %s", expr.Range(), value.Type().FriendlyName())
+	}
+	return !value.IsKnown() || value.True(), nil
+}
+
+// load_policies loads the policy files at paths, and in directories at
+// paths the files of a known policy language
+func load_policies(paths []string) ([]policyEngine, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading policies: %v", err)
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading policies: %v", err)
+		}
+		for _, entry := range entries {
+			if _, ok := policyLoaders[filepath.Ext(entry.Name())]; ok && !entry.IsDir() {
+				files = append(files, filepath.Join(path, entry.Name()))
+			}
+		}
+	}
+	sort.Strings(files)
+
+	var engines []policyEngine
+	for _, path := range files {
+		load, ok := policyLoaders[filepath.Ext(path)]
+		if !ok {
+			return nil, fmt.Errorf("%s: unknown policy language, expected one of %s", path, strings.Join(policy_extensions(), ", "))
+		}
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %v", path, err)
+		}
+		engine, err := load(configFile{Path: path, Src: src})
+		if err != nil {
+			return nil, err
+		}
+		engines = append(engines, engine)
+	}
+	return engines, nil
+}
+
+func policy_extensions() []string {
+	var extensions []string
+	for extension := range policyLoaders {
+		extensions = append(extensions, extension)
+	}
+	sort.Strings(extensions)
+	return extensions
+}
+
+// check_policies evaluates the policy files at paths against the objects
+// of snapshot and fails on any violation
+func check_policies(snapshot CorpSnapshot, paths []string, now time.Time) error {
+	if len(paths) == 0 {
+		return nil
+	}
+	engines, err := load_policies(paths)
+	if err != nil {
+		return err
+	}
+	model, err := policy_model(snapshot.Corp, import_set_from_snapshot(snapshot, nil))
+	if err != nil {
+		return err
+	}
+
+	var lines []string
+	for _, engine := range engines {
+		violations, err := engine.evaluate(model, now)
+		if err != nil {
+			return err
+		}
+		for _, violation := range violations {
+			lines = append(lines, fmt.Sprintf("%s: %s [%s]", violation.Address, violation.Message, violation.Policy))
+		}
+	}
+	if len(lines) > 0 {
+		return fmt.Errorf("%d policy violations:\n%s", len(lines), strings.Join(lines, "\n"))
+	}
+	return nil
+}
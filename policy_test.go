@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	sigsci "github.com/signalsciences/go-sigsci"
+)
+
+func TestPolicies(t *testing.T) {
+	_, sc, api := newFakeAPI(t, "testcorp")
+	snapshot, err := fetch_corp_snapshot(context.Background(), sc, api, "testcorp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// api2 allows its partner from a country list holding a sanctioned
+	// country
+	api2 := &snapshot.Sites[1]
+	api2.Lists.Data = append(api2.Lists.Data, sigsci.ResponseListBody{
+		ID:             "site.partner-countries",
+		CreateListBody: sigsci.CreateListBody{Name: "Partner countries", Type: "country", Entries: []string{"DE", "KP"}},
+	})
+	api2.Rules.Data[0].Conditions = append(api2.Rules.Data[0].Conditions, sigsci.Condition{Type: "single", Field: "country", Operator: "inList", Value: "site.partner-countries"})
+
+	err = check_policies(snapshot, []string{filepath.Join("testdata", "policies")}, time.Now())
+	if err == nil {
+		t.Fatal("expected violations")
+	}
+	want := strings.Join([]string{
+		"3 policy violations:",
+		"sigsci_site." + sanitizeTfId("api2") + ": site api2 is in log mode [sites_block]",
+		"sigsci_site_rule." + sanitizeTfId("61b2c3d4e5f60718293a4b5c") + ": Block rules need an expiration or a ticket in their reason [block_rules_expire]",
+		"sigsci_site_rule." + sanitizeTfId("66a718293a4b5c6d7e8f9012") + ": No rule may allow a country list holding sanctioned countries [no_sanctioned_countries_allowed]",
+	}, "\n")
+	if err.Error() != want {
+		t.Errorf("got\n%s\nwant\n%s", err, want)
+	}
+
+	// Fixing the violations passes
+	api2.Site.AgentLevel = "block"
+	api2.Lists.Data[len(api2.Lists.Data)-1].Entries = []string{"DE"}
+	snapshot.Sites[0].Rules.Data[0].Reason = "SEC-42 block admin from outside the office"
+	if err := check_policies(snapshot, []string{filepath.Join("testdata", "policies")}, time.Now()); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPolicyErrors(t *testing.T) {
+	snapshot := CorpSnapshot{Corp: "testcorp", Sites: []SiteSnapshot{{Site: sigsci.Site{Name: "www", AgentLevel: "block"}}}}
+	for name, src := range map[string]string{
+		"unknown attribute": `policy "p" {
+  resource  = "sigsci_site"
+  condition = true
+  severity  = "high"
+}`,
+		"not a bool": `policy "p" {
+  resource  = "sigsci_site"
+  condition = self.agent_level
+}`,
+		"missing condition": `policy "p" {
+  resource = "sigsci_site"
+}`,
+	} {
+		path := filepath.Join(t.TempDir(), "policy.hcl")
+		if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := check_policies(snapshot, []string{path}, time.Now()); err == nil || strings.Contains(err.Error(), "violations") {
+			t.Errorf("%s: expected an error, got %v", name, err)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "policy.rego")
+	if err := os.WriteFile(path, []byte("package ngwaf"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := check_policies(snapshot, []string{path}, time.Now()); err == nil || !strings.Contains(err.Error(), "unknown policy language") {
+		t.Errorf("expected an unknown language error, got %v", err)
+	}
+}
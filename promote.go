@@ -0,0 +1,319 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	sigsci "github.com/signalsciences/go-sigsci"
+)
+
+// Promotion copies a site's configuration from one corp to another
+type Promotion struct {
+	SourceCorp string
+	SourceSite string
+	TargetCorp string
+	TargetSite string
+	// ProviderAlias, when set, is used as the provider of every resource
+	ProviderAlias string
+	// ListFiles, when set, externalizes list entries, see
+	// externalize_list_entries
+	ListFiles string
+}
+
+// promotionRefs resolves list and signal references of the source site
+// for the target corp
+type promotionRefs struct {
+	promotion Promotion
+	// refs holds the site lists and signals promoted alongside the rules
+	refs references
+	// rewrite maps source corp list and signal IDs to their target IDs
+	rewrite map[string]string
+}
+
+// resolve returns the value to render for a reference and whether it has a
+// counterpart in the target
+func (p promotionRefs) resolve(value string) (string, bool) {
+	if _, ok := p.refs[value]; ok {
+		return value, true
+	}
+	if target, ok := p.rewrite[value]; ok {
+		return target, true
+	}
+	if strings.HasPrefix(value, "corp.") || strings.HasPrefix(value, "site.") {
+		return value, false
+	}
+	// System signals such as SQLI exist in every corp
+	return value, true
+}
+
+func isListOperator(operator string) bool {
+	return operator == "inList" || operator == "notInList"
+}
+
+// isSignalField reports whether the values of conditions on field are
+// signal names
+func isSignalField(field string) bool {
+	return field == "signal" || field == "signalType"
+}
+
+func (p promotionRefs) rewrite_conditions(conditions []sigsci.Condition, owner string, warnings *[]string) []sigsci.Condition {
+	var rewritten []sigsci.Condition
+	for _, condition := range conditions {
+		if isListOperator(condition.Operator) || isSignalField(condition.Field) {
+			value, ok := p.resolve(condition.Value)
+			if !ok {
+				*warnings = append(*warnings, p.missing(owner, condition.Value))
+			}
+			condition.Value = value
+		}
+		condition.Conditions = p.rewrite_conditions(condition.Conditions, owner, warnings)
+		rewritten = append(rewritten, condition)
+	}
+	return rewritten
+}
+
+func (p promotionRefs) rewrite_actions(actions []sigsci.Action, owner string, warnings *[]string) []sigsci.Action {
+	var rewritten []sigsci.Action
+	for _, action := range actions {
+		if action.Signal != "" {
+			value, ok := p.resolve(action.Signal)
+			if !ok {
+				*warnings = append(*warnings, p.missing(owner, action.Signal))
+			}
+			action.Signal = value
+		}
+		rewritten = append(rewritten, action)
+	}
+	return rewritten
+}
+
+func (p promotionRefs) rewrite_signal(signal string, owner string, warnings *[]string) string {
+	if signal == "" {
+		return signal
+	}
+	value, ok := p.resolve(signal)
+	if !ok {
+		*warnings = append(*warnings, p.missing(owner, signal))
+	}
+	return value
+}
+
+func (p promotionRefs) missing(owner string, reference string) string {
+	if strings.HasPrefix(reference, "site.") {
+		return fmt.Sprintf("%s references %s, which does not exist on site %s", owner, reference, p.promotion.SourceSite)
+	}
+	return fmt.Sprintf("%s references %s, which has no counterpart in corp %s", owner, reference, p.promotion.TargetCorp)
+}
+
+// promote_site renders the rules, lists, signals and alerts of the source
+// site as resources of the target site in outputDir/promote.tf. Corp list
+// and signal references are rewritten to the target corp's objects with the
+// same name. It returns the objects that could not be carried over as-is.
+func promote_site(source sigsci.Client, target sigsci.Client, p Promotion, outputDir string) ([]string, error) {
+	var warnings []string
+
+	sourceCorpLists, err := source.GetAllCorpLists(p.SourceCorp)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching corp lists of %s: %v", p.SourceCorp, err)
+	}
+	sourceCorpSignals, err := source.GetAllCorpSignalTags(p.SourceCorp)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching corp signals of %s: %v", p.SourceCorp, err)
+	}
+	siteRules, err := source.GetAllSiteRules(p.SourceCorp, p.SourceSite)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching rules of site %s: %v", p.SourceSite, err)
+	}
+	siteLists, err := source.GetAllSiteLists(p.SourceCorp, p.SourceSite)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching lists of site %s: %v", p.SourceSite, err)
+	}
+	siteSignals, err := source.GetAllSiteSignalTags(p.SourceCorp, p.SourceSite)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching signals of site %s: %v", p.SourceSite, err)
+	}
+	siteAlerts, err := source.ListCustomAlerts(p.SourceCorp, p.SourceSite)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching alerts of site %s: %v", p.SourceSite, err)
+	}
+
+	targetCorpLists, err := target.GetAllCorpLists(p.TargetCorp)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching corp lists of %s: %v", p.TargetCorp, err)
+	}
+	targetCorpSignals, err := target.GetAllCorpSignalTags(p.TargetCorp)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching corp signals of %s: %v", p.TargetCorp, err)
+	}
+	targetSites, err := target.ListSites(p.TargetCorp)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching sites of %s: %v", p.TargetCorp, err)
+	}
+
+	targetSiteExists := false
+	for _, site := range targetSites {
+		if site.Name == p.TargetSite {
+			targetSiteExists = true
+		}
+	}
+	if !targetSiteExists {
+		warnings = append(warnings, fmt.Sprintf("site %s does not exist in corp %s, create it before applying", p.TargetSite, p.TargetCorp))
+	}
+
+	pr := promotionRefs{promotion: p, refs: references{}, rewrite: map[string]string{}}
+
+	// Corp objects are matched by name, their IDs can differ between corps
+	for _, sourceList := range sourceCorpLists.Data {
+		for _, targetList := range targetCorpLists.Data {
+			if targetList.Name == sourceList.Name {
+				pr.rewrite[sourceList.ID] = targetList.ID
+			}
+		}
+	}
+	for _, sourceSignal := range sourceCorpSignals.Data {
+		for _, targetSignal := range targetCorpSignals.Data {
+			if targetSignal.ShortName == sourceSignal.ShortName {
+				pr.rewrite[sourceSignal.TagName] = targetSignal.TagName
+			}
+		}
+	}
+
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return nil, err
+	}
+
+	file := hclwrite.NewEmptyFile()
+
+	// Site lists and signals are promoted with the rules and referenced
+	// directly, so Terraform creates them first
+	for _, item := range siteLists.Data {
+		name := p.TargetSite + sanitizeTfId(item.ID)
+		pr.refs[item.ID] = resourceTraversal("sigsci_site_list", name, "id")
+		config := list_config(p.TargetSite, item.CreateListBody)
+		if p.ListFiles != "" {
+			if err := externalize_list_entries(config, outputDir, name, p.ListFiles); err != nil {
+				return nil, err
+			}
+		}
+		render_resource(file, "sigsci_site_list", name, config)
+	}
+	for _, item := range siteSignals.Data {
+		name := p.TargetSite + sanitizeTfId(item.TagName)
+		pr.refs[item.TagName] = resourceTraversal("sigsci_site_signal_tag", name, "id")
+		render_site_signal_tag_resource(file, name, p.TargetSite, item.CreateSignalTagBody)
+	}
+
+	for _, item := range siteRules.Data {
+		owner := fmt.Sprintf("site rule %s (%s)", item.ID, item.Reason)
+		rule := item.CreateSiteRuleBody
+		rule.Conditions = pr.rewrite_conditions(rule.Conditions, owner, &warnings)
+		rule.Actions = pr.rewrite_actions(rule.Actions, owner, &warnings)
+		rule.Signal = pr.rewrite_signal(rule.Signal, owner, &warnings)
+		render_site_rule_resource(file, sanitizeTfId(item.ID), p.TargetSite, rule, pr.refs)
+	}
+
+	for _, item := range siteAlerts {
+		owner := fmt.Sprintf("alert %s (%s)", item.ID, item.LongName)
+		// Templated rules are not promoted, nor are their alerts
+		class := classify_alert(p.SourceSite, nil, item)
+		switch class.ResourceType {
+		case "sigsci_site_alert":
+			item.TagName = pr.rewrite_signal(item.TagName, owner, &warnings)
+			render_site_alert_resource(file, sanitizeTfId(item.ID), p.TargetSite, item, pr.refs)
+		case "sigsci_site_agent_alert":
+			render_site_agent_alert_resource(file, sanitizeTfId(item.ID), p.TargetSite, item)
+		default:
+			warnings = append(warnings, fmt.Sprintf("%s: %s, not promoted", owner, class.Reason))
+		}
+	}
+
+	if p.ProviderAlias != "" {
+		set_provider_alias(file.Body(), "resource", p.ProviderAlias)
+	}
+
+	if err := write_terraform_config_file(file, filepath.Join(outputDir, "promote.tf")); err != nil {
+		return nil, err
+	}
+	return warnings, nil
+}
+
+// corp_client returns a client for corp, using its credentials from cfg
+// when listed there and the TF_VAR_NGWAF_* credentials otherwise
+func corp_client(cfg Config, corp string) (sigsci.Client, string, error) {
+	if corpConfig, ok := cfg.Corp(corp); ok {
+		email, token, err := corpConfig.Credentials()
+		if err != nil {
+			return sigsci.Client{}, "", err
+		}
+		return sigsci.NewTokenClient(email, token), corpConfig.ProviderAlias, nil
+	}
+	return sigsci.NewTokenClient(os.Getenv("TF_VAR_NGWAF_EMAIL"), os.Getenv("TF_VAR_NGWAF_TOKEN")), "", nil
+}
+
+// run_promote implements the promote command
+func run_promote(args []string) error {
+	flags := flag.NewFlagSet("promote", flag.ExitOnError)
+	sourceCorp := flags.String("source-corp", os.Getenv("TF_VAR_NGWAF_CORP"), "corp to copy the site from")
+	sourceSite := flags.String("source-site", "", "site to copy")
+	targetCorp := flags.String("target-corp", "", "corp to copy the site to")
+	targetSite := flags.String("target-site", "", "site name in the target corp, defaults to -source-site")
+	outputDir := flags.String("out", "promoted", "directory to write promote.tf to")
+	configPath := flags.String("config", "", "corps config file with per-corp credentials and provider aliases")
+	format := flags.String("format", formatHCL, "output syntax, hcl or json (.tf.json)")
+	listFiles := flags.String("list-files", "", "write list entries to lists/<name>.txt or .json (txt or json)")
+	flags.Parse(args)
+
+	if err := validFormat(*format); err != nil {
+		return err
+	}
+	if err := validListFiles(*listFiles); err != nil {
+		return err
+	}
+
+	if *sourceSite == "" || *targetCorp == "" {
+		flags.Usage()
+		return fmt.Errorf("promote needs -source-site and -target-corp")
+	}
+	if *targetSite == "" {
+		*targetSite = *sourceSite
+	}
+
+	var cfg Config
+	if *configPath != "" {
+		var err error
+		if cfg, err = LoadConfig(*configPath); err != nil {
+			return err
+		}
+	}
+	source, _, err := corp_client(cfg, *sourceCorp)
+	if err != nil {
+		return err
+	}
+	target, alias, err := corp_client(cfg, *targetCorp)
+	if err != nil {
+		return err
+	}
+
+	warnings, err := promote_site(source, target, Promotion{
+		SourceCorp:    *sourceCorp,
+		SourceSite:    *sourceSite,
+		TargetCorp:    *targetCorp,
+		TargetSite:    *targetSite,
+		ProviderAlias: alias,
+		ListFiles:     *listFiles,
+	}, *outputDir)
+	if err != nil {
+		return err
+	}
+	for _, warning := range warnings {
+		fmt.Println("WARNING:", warning)
+	}
+	if *format == formatJSON {
+		return convert_terraform_files_to_json(*outputDir, "promote.tf")
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestPromoteSite(t *testing.T) {
+	_, sc, _ := newFakeAPI(t, "testcorp", "prodcorp")
+	outputDir := t.TempDir()
+
+	warnings, err := promote_site(sc, sc, Promotion{
+		SourceCorp:    "testcorp",
+		SourceSite:    "www",
+		TargetCorp:    "prodcorp",
+		TargetSite:    "www-prod",
+		ProviderAlias: "prod",
+	}, outputDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertGolden(t, filepath.Join(outputDir, "promote.tf"), filepath.Join("promote", "promote.tf"))
+
+	want := []string{
+		"site rule 61b2c3d4e5f60718293a4b60 (Tag traffic from blocked IPs) references corp.bad-bot, which has no counterpart in corp prodcorp",
+		"alert 65f60718293a4b5c6d7e8f91 (LOGINATTEMPT alert): belongs to templated rule LOGINATTEMPT, which is not rendered, not promoted",
+	}
+	if !slices.Equal(warnings, want) {
+		t.Errorf("got warnings\n%q\nwant\n%q", warnings, want)
+	}
+}
+
+func TestPromoteSiteMissingTargetSite(t *testing.T) {
+	_, sc, _ := newFakeAPI(t, "testcorp", "prodcorp")
+
+	warnings, err := promote_site(sc, sc, Promotion{
+		SourceCorp: "testcorp",
+		SourceSite: "api2",
+		TargetCorp: "prodcorp",
+		TargetSite: "api2",
+	}, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Contains(warnings, "site api2 does not exist in corp prodcorp, create it before applying") {
+		t.Errorf("missing target site not reported: %q", warnings)
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+// pulumiHeader is the comment at the top of the generated Pulumi.yaml
+const pulumiHeader = `# Generated by ngwaf-terraformify.
+# Run "pulumi package add terraform-provider signalsciences/sigsci" to add the
+# bridged provider, then set its credentials with
+#   pulumi config set sigsci:email <email>
+#   pulumi config set --secret sigsci:authToken <token>
+# and the secret configuration keys below with pulumi config set --secret.
+`
+
+// pulumiType is the bridged provider's type token for resourceType, e.g.
+// sigsci:index/siteRule:SiteRule for sigsci_site_rule
+func pulumiType(resourceType string) string {
+	name := strings.TrimPrefix(resourceType, "sigsci_")
+	return "sigsci:index/" + camelCase(name) + ":" + pascalCase(name)
+}
+
+// pulumiName is the logical name of a target's resource. Terraform names
+// are only unique per resource type, Pulumi names must be unique per program.
+func pulumiName(resourceType string, name string) string {
+	return resourceType + "_" + name
+}
+
+// write_pulumi_yaml writes Pulumi.yaml, a Pulumi YAML program declaring
+// every import target as a resource of the bridged sigsci provider with an
+// import option for its ID
+func write_pulumi_yaml(imports *ImportSet, corp string, outputDir string) error {
+	var out bytes.Buffer
+	out.WriteString(pulumiHeader)
+	fmt.Fprintf(&out, "name: %s\n", jsString("ngwaf-"+corp))
+	out.WriteString("runtime: yaml\n")
+	fmt.Fprintf(&out, "description: %s\n", jsString("NGWAF configuration of corp "+corp))
+	var resources bytes.Buffer
+	var secrets []secretVariable
+	for _, target := range imports.Targets {
+		config, err := secret_target_config(target, &secrets)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&resources, "  %s:\n", jsString(pulumiName(target.ResourceType, target.Name)))
+		fmt.Fprintf(&resources, "    type: %s\n", pulumiType(target.ResourceType))
+		resources.WriteString("    properties:")
+		yaml_mapping(&resources, config, 3)
+		resources.WriteString("    options:\n")
+		fmt.Fprintf(&resources, "      import: %s\n", pulumiString(target.ID))
+	}
+
+	out.WriteString("config:\n")
+	fmt.Fprintf(&out, "  sigsci:corp: %s\n", jsString(corp))
+	for _, secret := range secrets {
+		fmt.Fprintf(&out, "  %s:\n", secret.Name)
+		out.WriteString("    type: string\n")
+		fmt.Fprintf(&out, "    description: %s\n", jsString(secret.Description))
+		out.WriteString("    secret: true\n")
+	}
+	out.WriteString("resources:")
+	if len(imports.Targets) == 0 {
+		out.WriteString(" {}")
+	}
+	out.WriteString("\n")
+	out.Write(resources.Bytes())
+
+	if err := os.WriteFile(filepath.Join(outputDir, "Pulumi.yaml"), out.Bytes(), 0666); err != nil {
+		return fmt.Errorf("error writing Pulumi.yaml: %v", err)
+	}
+	return nil
+}
+
+// yaml_mapping writes config as a YAML block mapping at depth levels of
+// indentation, starting on a new line. Strings are written JSON-quoted,
+// which YAML reads as double-quoted scalars.
+func yaml_mapping(out *bytes.Buffer, config *tfBlock, depth int) {
+	if len(config.Attrs) == 0 && len(config.Blocks) == 0 {
+		out.WriteString(" {}\n")
+		return
+	}
+	out.WriteString("\n")
+	indent := strings.Repeat("  ", depth)
+	for _, attr := range config.Attrs {
+		fmt.Fprintf(out, "%s%s: %s\n", indent, camelCase(attr.Name), yaml_value(attr.Value))
+	}
+	for _, group := range group_blocks(config.Blocks) {
+		fmt.Fprintf(out, "%s%s:", indent, camelCase(group.Type))
+		if singleBlocks[group.Type] {
+			yaml_mapping(out, group.Bodies[0], depth+1)
+			continue
+		}
+		out.WriteString("\n")
+		for _, body := range group.Bodies {
+			// Write the item one level deeper, then turn the indentation of
+			// its first line into the sequence marker
+			var item bytes.Buffer
+			yaml_mapping(&item, body, depth+2)
+			lines := strings.SplitN(strings.TrimPrefix(item.String(), "\n"), "\n", 2)
+			fmt.Fprintf(out, "%s  - %s\n", indent, strings.TrimLeft(lines[0], " "))
+			if len(lines) > 1 {
+				out.WriteString(lines[1])
+			}
+		}
+	}
+}
+
+// pulumiString quotes s, escaping what Pulumi YAML would interpolate
+func pulumiString(s string) string {
+	return jsString(strings.ReplaceAll(s, "${", "$${"))
+}
+
+func yaml_value(value interface{}) string {
+	switch value := value.(type) {
+	case string:
+		return pulumiString(value)
+	case int:
+		return strconv.Itoa(value)
+	case bool:
+		return strconv.FormatBool(value)
+	case []string:
+		var items []string
+		for _, item := range value {
+			items = append(items, pulumiString(item))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case hcl.Traversal:
+		return jsString(pulumi_reference(value))
+	case tfVariable:
+		return jsString("${" + string(value) + "}")
+	}
+	return "null"
+}
+
+// pulumi_reference turns a resource attribute reference into a Pulumi YAML
+// interpolation of the same attribute
+func pulumi_reference(traversal hcl.Traversal) string {
+	if len(traversal) != 3 {
+		return traversalString(traversal)
+	}
+	resourceType := traversal[0].(hcl.TraverseRoot).Name
+	name := traversal[1].(hcl.TraverseAttr).Name
+	attr := traversal[2].(hcl.TraverseAttr).Name
+	return "${" + pulumiName(resourceType, name) + "." + camelCase(attr) + "}"
+}
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestPulumiYAMLGolden(t *testing.T) {
+	_, sc, api := newFakeAPI(t, "testcorp")
+	outputDir := t.TempDir()
+
+	opts := RunOptions{Format: formatHCL, Output: outputPulumiYAML}
+	if err := terraformify_corp(context.Background(), sc, api, "testcorp", outputDir, opts, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	assertGolden(t, filepath.Join(outputDir, "pulumi", "Pulumi.yaml"), filepath.Join("testcorp", "pulumi-yaml", "Pulumi.yaml"))
+}
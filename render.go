@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	sigsci "github.com/signalsciences/go-sigsci"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// references maps list and signal IDs to the Terraform attribute that will
+// hold them, so rendered rules depend on the resources they refer to
+type references map[string]hcl.Traversal
+
+func resourceTraversal(resourceType string, name string, attr string) hcl.Traversal {
+	return hcl.Traversal{
+		hcl.TraverseRoot{Name: resourceType},
+		hcl.TraverseAttr{Name: name},
+		hcl.TraverseAttr{Name: attr},
+	}
+}
+
+func stringListVal(values []string) cty.Value {
+	if len(values) == 0 {
+		return cty.ListValEmpty(cty.String)
+	}
+	var vals []cty.Value
+	for _, value := range values {
+		vals = append(vals, cty.StringVal(value))
+	}
+	return cty.ListVal(vals)
+}
+
+// render_site_rule_resource appends a sigsci_site_rule resource for rule
+func render_site_rule_resource(file *hclwrite.File, name string, siteShortName string, rule sigsci.CreateSiteRuleBody, refs references) {
+	render_resource(file, "sigsci_site_rule", name, site_rule_config(siteShortName, rule, refs))
+}
+
+// render_site_signal_tag_resource appends a sigsci_site_signal_tag resource
+func render_site_signal_tag_resource(file *hclwrite.File, name string, siteShortName string, tag sigsci.CreateSignalTagBody) {
+	render_resource(file, "sigsci_site_signal_tag", name, site_signal_tag_config(siteShortName, tag))
+}
+
+// render_site_alert_resource appends a sigsci_site_alert resource
+func render_site_alert_resource(file *hclwrite.File, name string, siteShortName string, alert sigsci.CustomAlert, refs references) {
+	render_resource(file, "sigsci_site_alert", name, site_alert_config(siteShortName, alert, refs))
+}
+
+// render_site_agent_alert_resource appends a sigsci_site_agent_alert resource
+func render_site_agent_alert_resource(file *hclwrite.File, name string, siteShortName string, alert sigsci.CustomAlert) {
+	render_resource(file, "sigsci_site_agent_alert", name, site_agent_alert_config(siteShortName, alert))
+}
+
+// write_terraform_config_file replaces fileName with the contents of hclFile
+func write_terraform_config_file(hclFile *hclwrite.File, fileName string) error {
+	if err := os.WriteFile(fileName, hclwrite.Format(hclFile.Bytes()), 0666); err != nil {
+		return fmt.Errorf("error writing %s: %v", fileName, err)
+	}
+	return nil
+}
@@ -0,0 +1,484 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	sigsci "github.com/signalsciences/go-sigsci"
+)
+
+// ruleTestFile is the fixture file of a site: requests that should and
+// should not match each of its rules
+type ruleTestFile struct {
+	Site  string         `json:"site"`
+	Cases []ruleTestCase `json:"cases"`
+}
+
+// ruleTestCase is a request and whether a rule matches it, and with which
+// actions
+type ruleTestCase struct {
+	Name    string     `json:"name"`
+	Rule    string     `json:"rule"`
+	Request simRequest `json:"request"`
+	Match   bool       `json:"match"`
+	Actions []string   `json:"actions,omitempty"`
+}
+
+// simulationSource returns the rules of a site and the lists they can
+// reference
+type simulationSource func(site string) ([]simRule, map[string]sigsci.CreateListBody, error)
+
+// rule_matches evaluates a single rule against request, without the
+// signals earlier rules would add
+func rule_matches(rule simRule, request simRequest, lists map[string]sigsci.CreateListBody) (bool, []string) {
+	result := simulate(request, []simRule{rule}, lists, 406)
+	if len(result.Matches) == 0 {
+		return false, nil
+	}
+	return true, result.Matches[0].Actions
+}
+
+// base_request is the request cases start from
+func base_request() simRequest {
+	return simRequest{Method: "GET", Scheme: "https", Path: "/", Query: url.Values{}, Headers: http.Header{}, IP: "192.0.2.254"}
+}
+
+// generate_rule_tests derives a matching and a non-matching request for
+// every enabled rule from its conditions, keeping only requests the rule
+// indeed does or does not match. It returns the cases and the rules no
+// request could be derived for.
+func generate_rule_tests(site string, rules []simRule, lists map[string]sigsci.CreateListBody) (ruleTestFile, []string) {
+	file := ruleTestFile{Site: site, Cases: []ruleTestCase{}}
+	var skipped []string
+	for _, rule := range rules {
+		if !rule.Body.Enabled {
+			continue
+		}
+		for _, want := range []bool{true, false} {
+			request := base_request()
+			builder := requestBuilder{request: &request, lists: lists}
+			builder.group(rule.Body.GroupOperator, rule.Body.Conditions, want)
+			matched, actions := rule_matches(rule, request, lists)
+			if matched != want {
+				skipped = append(skipped, fmt.Sprintf("%s: no request %s could be derived", rule.Name, map[bool]string{true: "matching", false: "not matching"}[want]))
+				continue
+			}
+			name := rule.Name + " matches"
+			if !want {
+				name = rule.Name + " does not match"
+			}
+			file.Cases = append(file.Cases, ruleTestCase{Name: name, Rule: rule.Name, Request: request, Match: want, Actions: actions})
+		}
+	}
+	return file, skipped
+}
+
+// requestBuilder changes a request to satisfy or violate conditions
+type requestBuilder struct {
+	request *simRequest
+	lists   map[string]sigsci.CreateListBody
+}
+
+// group makes the group of conditions match, or not. An all group needs
+// every condition and an any group one condition to match; an all group
+// fails with one condition failing and an any group with all of them.
+func (b requestBuilder) group(operator string, conditions []sigsci.Condition, want bool) {
+	if len(conditions) == 0 {
+		return
+	}
+	if (operator == "any") == want {
+		b.condition(conditions[0], want)
+		return
+	}
+	for _, condition := range conditions {
+		b.condition(condition, want)
+	}
+}
+
+func (b requestBuilder) condition(condition sigsci.Condition, want bool) {
+	switch condition.Type {
+	case "group":
+		b.group(condition.GroupOperator, condition.Conditions, want)
+		return
+	case "multival":
+		// Without pairs of the field nothing matches
+		if want {
+			b.multival(condition)
+		}
+		return
+	}
+
+	if positive, ok := simNegations[condition.Operator]; ok {
+		condition.Operator = positive
+		want = !want
+	}
+	switch {
+	case condition.Operator == "exists" && want, condition.Operator == "doesNotExist" && !want:
+		b.set(condition.Field, example_value(condition.Field))
+	case condition.Operator == "exists", condition.Operator == "doesNotExist":
+		b.set(condition.Field, "")
+	default:
+		if value, ok := b.value(condition, want); ok {
+			b.set(condition.Field, value)
+		}
+	}
+}
+
+// multival adds a name and value pair satisfying the conditions of a
+// multival condition
+func (b requestBuilder) multival(condition sigsci.Condition) {
+	pair := [2]string{"x-example", "example"}
+	for _, nested := range condition.Conditions {
+		index := 1
+		if nested.Field == "name" {
+			index = 0
+		}
+		want := true
+		if positive, ok := simNegations[nested.Operator]; ok {
+			nested.Operator = positive
+			want = false
+		}
+		if value, ok := b.value(nested, want); ok {
+			pair[index] = value
+		}
+	}
+	switch condition.Field {
+	case "requestHeader":
+		b.request.Headers.Add(pair[0], pair[1])
+	case "requestCookie":
+		b.request.Headers.Add("Cookie", pair[0]+"="+pair[1])
+	default:
+		b.request.Query.Add(pair[0], pair[1])
+	}
+}
+
+// value returns a value of the condition's field that matches, or not,
+// its positive operator
+func (b requestBuilder) value(condition sigsci.Condition, want bool) (string, bool) {
+	var candidates []string
+	if want {
+		switch condition.Operator {
+		case "equals":
+			candidates = []string{condition.Value}
+		case "contains":
+			candidates = []string{"x" + condition.Value + "x", condition.Value}
+		case "prefix":
+			candidates = []string{condition.Value + "x", condition.Value}
+		case "suffix":
+			candidates = []string{"x" + condition.Value, condition.Value}
+		case "like":
+			candidates = []string{strings.ReplaceAll(condition.Value, "*", "x")}
+		case "matches":
+			literal := regexAnchors.ReplaceAllString(condition.Value, "$1")
+			candidates = []string{literal, condition.Value}
+		case "greaterEqual", "lesserEqual":
+			candidates = []string{condition.Value}
+		case "inList":
+			candidates = list_examples(b.lists[condition.Value])
+		}
+	} else {
+		candidates = []string{example_value(condition.Field), "zz-no-match"}
+		if number, err := strconv.Atoi(condition.Value); err == nil {
+			candidates = append([]string{strconv.Itoa(number - 1), strconv.Itoa(number + 1)}, candidates...)
+		}
+	}
+
+	evaluator := simEvaluator{lists: b.lists}
+	for _, candidate := range candidates {
+		if evaluator.match_value(condition, candidate) == want {
+			return candidate, true
+		}
+	}
+	return "", false
+}
+
+// regexAnchors are the anchors and escapes of a regular expression, which
+// removed often leave a string it matches
+var regexAnchors = regexp.MustCompile(`\\(.)|[\^$]`)
+
+// list_examples are values in list
+func list_examples(list sigsci.CreateListBody) []string {
+	var examples []string
+	for _, entry := range list.Entries {
+		switch {
+		case list.Type == "ip" && strings.Contains(entry, "/"):
+			if _, network, err := net.ParseCIDR(entry); err == nil {
+				examples = append(examples, network.IP.String())
+			}
+		case list.Type == "wildcard":
+			examples = append(examples, strings.ReplaceAll(entry, "*", "x"))
+		default:
+			examples = append(examples, entry)
+		}
+	}
+	return examples
+}
+
+// example_value is a plausible value of field
+func example_value(field string) string {
+	switch field {
+	case "ip", "valueIp":
+		return "192.0.2.254"
+	case "country":
+		return "ZZ"
+	case "method":
+		return "GET"
+	case "scheme":
+		return "https"
+	case "path", "uri":
+		return "/zz-no-match"
+	case "responseCode", "valueInt":
+		return "200"
+	}
+	return "zz-example"
+}
+
+// set sets field of the request, or removes it when value is empty
+func (b requestBuilder) set(field string, value string) {
+	request := b.request
+	switch field {
+	case "scheme":
+		request.Scheme = value
+	case "method":
+		request.Method = value
+	case "path", "uri":
+		request.Path = value
+		if value == "" {
+			request.Path = "/"
+		}
+	case "domain":
+		request.Domain = value
+		request.Headers.Del("Host")
+		if value != "" {
+			request.Headers.Set("Host", value)
+		}
+	case "useragent":
+		request.Headers.Del("User-Agent")
+		if value != "" {
+			request.Headers.Set("User-Agent", value)
+		}
+	case "ip":
+		request.IP = value
+	case "country":
+		request.Country = value
+	case "paramname", "queryParameter":
+		if value == "" {
+			request.Query = url.Values{}
+		} else {
+			request.Query.Set(value, "1")
+		}
+	case "paramvalue":
+		if value == "" {
+			request.Query = url.Values{}
+		} else {
+			request.Query.Set("q", value)
+		}
+	case "requestHeader":
+		if value != "" {
+			request.Headers.Set(value, "1")
+		}
+	case "requestCookie":
+		request.Headers.Del("Cookie")
+		if value != "" {
+			request.Headers.Set("Cookie", value+"=1")
+		}
+	case "signalType":
+		request.Signals = nil
+		if value != "" {
+			request.Signals = []string{value}
+		}
+	}
+}
+
+// check_rule_tests re-evaluates the cases of file against the current rules.
+// It returns the failures, the cases of rules that no longer exist
+// included, and the rules without cases.
+func check_rule_tests(file ruleTestFile, rules []simRule, lists map[string]sigsci.CreateListBody) ([]string, []string) {
+	byName := map[string]simRule{}
+	for _, rule := range rules {
+		byName[rule.Name] = rule
+	}
+	var failures []string
+	tested := map[string]bool{}
+	for _, c := range file.Cases {
+		tested[c.Rule] = true
+		rule, ok := byName[c.Rule]
+		if !ok {
+			failures = append(failures, fmt.Sprintf("%s: rule %s no longer exists", c.Name, c.Rule))
+			continue
+		}
+		if !rule.Body.Enabled {
+			failures = append(failures, fmt.Sprintf("%s: rule %s is disabled", c.Name, c.Rule))
+			continue
+		}
+		matched, actions := rule_matches(rule, c.Request, lists)
+		switch {
+		case matched != c.Match && c.Match:
+			failures = append(failures, fmt.Sprintf("%s: the rule no longer matches the request", c.Name))
+		case matched != c.Match:
+			failures = append(failures, fmt.Sprintf("%s: the rule now matches the request", c.Name))
+		case matched && !slices.Equal(actions, c.Actions):
+			failures = append(failures, fmt.Sprintf("%s: actions are %v, expected %v", c.Name, actions, c.Actions))
+		}
+	}
+	var untested []string
+	for _, rule := range rules {
+		if rule.Body.Enabled && !tested[rule.Name] {
+			untested = append(untested, rule.Name)
+		}
+	}
+	return failures, untested
+}
+
+// rule_test_sources returns the source of the rules, the configuration
+// files when there are any, else the corp, and the sites it has
+func rule_test_sources(ctx context.Context, sc sigsci.Client, api *APIClient, corp string, paths []string) (simulationSource, []string, error) {
+	if len(paths) == 0 {
+		snapshot, err := fetch_corp_snapshot(ctx, sc, api, corp)
+		if err != nil {
+			return nil, nil, err
+		}
+		var sites []string
+		for _, site := range snapshot.Sites {
+			sites = append(sites, site.Site.Name)
+		}
+		return func(site string) ([]simRule, map[string]sigsci.CreateListBody, error) {
+			rules, lists, _, err := snapshot_simulation(snapshot, site)
+			return rules, lists, err
+		}, sites, nil
+	}
+
+	files, err := read_config_files(paths)
+	if err != nil {
+		return nil, nil, err
+	}
+	resources, err := decode_resources(files, filepath.Dir(paths[0]), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	siteSet := map[string]bool{}
+	for _, resource := range resources {
+		if resource.Site != "" {
+			siteSet[resource.Site] = true
+		}
+	}
+	var sites []string
+	for site := range siteSet {
+		sites = append(sites, site)
+	}
+	sort.Strings(sites)
+	return func(site string) ([]simRule, map[string]sigsci.CreateListBody, error) {
+		return config_rule_simulation(resources, site)
+	}, sites, nil
+}
+
+// config_rule_simulation is config_simulation with the rules named like the
+// tool names the resources of their objects, also for compact
+// configuration, so fixtures name the same rules whatever the source
+func config_rule_simulation(resources []apiResource, siteName string) ([]simRule, map[string]sigsci.CreateListBody, error) {
+	rules, lists, err := config_simulation(resources, siteName)
+	if err != nil {
+		return nil, nil, err
+	}
+	names := map[string]string{}
+	for _, resource := range resources {
+		names[resource.Address] = resource.Type + "." + resource.Name
+	}
+	for i := range rules {
+		rules[i].Name = names[rules[i].Name]
+	}
+	return rules, lists, nil
+}
+
+func run_rule_tests(ctx context.Context, sc sigsci.Client, api *APIClient, args []string) error {
+	flags := flag.NewFlagSet("rule-tests", flag.ExitOnError)
+	corp := flags.String("corp", os.Getenv("TF_VAR_NGWAF_CORP"), "corp to fetch the rules from, without configuration files")
+	dir := flags.String("dir", "rule-tests", "directory of the fixture files, one <site>.json per site")
+	generate := flags.Bool("generate", false, "write the fixture files instead of running them")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: rule-tests [-generate] [-dir rule-tests] [file.tf ...]")
+		fmt.Fprintln(flags.Output(), "Uses the rules of the configuration files, or without files the rules fetched from the corp.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	source, sites, err := rule_test_sources(ctx, sc, api, *corp, flags.Args())
+	if err != nil {
+		return err
+	}
+
+	if *generate {
+		if err := os.MkdirAll(*dir, 0755); err != nil {
+			return err
+		}
+		for _, site := range sites {
+			rules, lists, err := source(site)
+			if err != nil {
+				return err
+			}
+			file, skipped := generate_rule_tests(site, rules, lists)
+			for _, message := range skipped {
+				fmt.Println("WARNING:", message)
+			}
+			content, err := json.MarshalIndent(file, "", "  ")
+			if err != nil {
+				return err
+			}
+			path := filepath.Join(*dir, site+".json")
+			if err := os.WriteFile(path, append(content, '\n'), 0666); err != nil {
+				return fmt.Errorf("error writing %s: %v", path, err)
+			}
+			fmt.Printf("wrote %d cases to %s\n", len(file.Cases), path)
+		}
+		return nil
+	}
+
+	paths, err := filepath.Glob(filepath.Join(*dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("no fixture files in %s, write them with -generate", *dir)
+	}
+	failed, total := 0, 0
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("error reading %s: %v", path, err)
+		}
+		var file ruleTestFile
+		if err := json.Unmarshal(content, &file); err != nil {
+			return fmt.Errorf("error reading %s: %v", path, err)
+		}
+		rules, lists, err := source(file.Site)
+		if err != nil {
+			return err
+		}
+		failures, untested := check_rule_tests(file, rules, lists)
+		for _, failure := range failures {
+			fmt.Printf("FAIL %s: %s\n", path, failure)
+		}
+		for _, rule := range untested {
+			fmt.Printf("%s: rule %s has no cases, regenerate to cover it\n", path, rule)
+		}
+		failed += len(failures)
+		total += len(file.Cases)
+	}
+	fmt.Printf("%d cases, %d failed\n", total, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d rule tests failed", failed)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRuleTests(t *testing.T) {
+	_, sc, api := newFakeAPI(t, "testcorp")
+	snapshot, err := fetch_corp_snapshot(context.Background(), sc, api, "testcorp")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outputDir := t.TempDir()
+	opts := RunOptions{Format: formatHCL, Output: outputTerraform, Compact: true, ListFiles: listFilesTxt}
+	if err := terraformify_corp(context.Background(), sc, api, "testcorp", outputDir, opts, ""); err != nil {
+		t.Fatal(err)
+	}
+	files, err := read_config_files([]string{filepath.Join(outputDir, "import.tf")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TF_VAR_site_integration_"+sanitizeTfId("62c3d4e5f60718293a4b5c6d")+"_url", "https://hooks.example.com/x")
+	resources, err := decode_resources(files, outputDir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rules, lists, _, err := snapshot_simulation(snapshot, "www")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, skipped := generate_rule_tests("www", rules, lists)
+	if len(skipped) > 0 {
+		t.Errorf("requests not derived: %v", skipped)
+	}
+	if len(file.Cases) == 0 {
+		t.Fatal("no cases generated")
+	}
+	for _, c := range file.Cases {
+		if strings.HasSuffix(c.Name, " matches") != c.Match {
+			t.Errorf("%s: match is %v", c.Name, c.Match)
+		}
+	}
+
+	// The fetched rules pass their own cases
+	failures, untested := check_rule_tests(file, rules, lists)
+	if len(failures) > 0 || len(untested) > 0 {
+		t.Errorf("fetched rules: failures %v, untested %v", failures, untested)
+	}
+
+	// And so do the rendered rules, but for rules the tool does not import
+	rendered, renderedLists, err := config_rule_simulation(resources, "www")
+	if err != nil {
+		t.Fatal(err)
+	}
+	failures, _ = check_rule_tests(file, rendered, renderedLists)
+	for _, failure := range failures {
+		if !strings.Contains(failure, "no longer exists") {
+			t.Errorf("rendered rules: %s", failure)
+		}
+	}
+
+	// Editing a condition value breaks the cases of the rule
+	admin := "sigsci_site_rule." + sanitizeTfId("61b2c3d4e5f60718293a4b5c")
+	for i := range rendered {
+		if rendered[i].Name == admin {
+			rendered[i].Body.Conditions[0].Value = "/administration"
+		}
+	}
+	failures, _ = check_rule_tests(file, rendered, renderedLists)
+	found := false
+	for _, failure := range failures {
+		found = found || strings.HasPrefix(failure, admin+" matches: the rule no longer matches")
+	}
+	if !found {
+		t.Errorf("edited rule not caught: %v", failures)
+	}
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// defaultProviderVersion is the sigsci provider constraint of the generated
+// root modules
+const defaultProviderVersion = ">= 3.0.1"
+
+// Supported state backends of the generated root modules
+const (
+	backendLocal = "local"
+	backendS3    = "s3"
+	backendHTTP  = "http"
+)
+
+func validBackend(backend string) error {
+	switch backend {
+	case "", backendLocal, backendS3, backendHTTP:
+		return nil
+	}
+	return fmt.Errorf("unknown backend %q, expected %s, %s or %s", backend, backendLocal, backendS3, backendHTTP)
+}
+
+// gitignoreEntries keep state, credentials and provider downloads of the
+// generated root modules out of version control
+var gitignoreEntries = []string{
+	".terraform/",
+	"*.tfstate",
+	"*.tfstate.*",
+	"*.tfvars",
+	"crash.log",
+}
+
+// RootModule describes the Terraform scaffolding that makes an output
+// directory a runnable root module
+type RootModule struct {
+	// Corp is written into the provider block, empty reads it from
+	// var.NGWAF_CORP instead
+	Corp string
+	// ProviderAlias, when set, aliases the provider block
+	ProviderAlias string
+	// ProviderVersion is the sigsci version constraint
+	ProviderVersion string
+	// Backend is the state backend type, empty for Terraform's default
+	Backend string
+	// BackendConfig holds the backend arguments. "{corp}" in a value is
+	// replaced with the corp name, so corps sharing a bucket get their own
+	// state.
+	BackendConfig map[string]string
+}
+
+// keyValueFlag collects repeated key=value flags
+type keyValueFlag map[string]string
+
+func (f keyValueFlag) String() string {
+	var pairs []string
+	for key, value := range f {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (f keyValueFlag) Set(pair string) error {
+	key, value, ok := strings.Cut(pair, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("expected key=value, got %q", pair)
+	}
+	f[key] = value
+	return nil
+}
+
+// stringsFlag collects repeated flags
+type stringsFlag []string
+
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *stringsFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+func varTraversal(name string) hcl.Traversal {
+	return hcl.Traversal{
+		hcl.TraverseRoot{Name: "var"},
+		hcl.TraverseAttr{Name: name},
+	}
+}
+
+// backendValue types the boolean backend arguments, such as s3's encrypt
+func backendValue(value string) cty.Value {
+	switch value {
+	case "true":
+		return cty.True
+	case "false":
+		return cty.False
+	}
+	return cty.StringVal(value)
+}
+
+// write_root_module writes providers.tf, variables.tf and the .gitignore
+// entries of a root module for corp to dir
+func write_root_module(dir string, corp string, module RootModule) error {
+	version := module.ProviderVersion
+	if version == "" {
+		version = defaultProviderVersion
+	}
+
+	providers := hclwrite.NewEmptyFile()
+
+	terraform := providers.Body().AppendNewBlock("terraform", nil)
+	requiredProviders := terraform.Body().AppendNewBlock("required_providers", nil)
+	requiredProviders.Body().SetAttributeValue("sigsci", cty.ObjectVal(map[string]cty.Value{
+		"source":  cty.StringVal("signalsciences/sigsci"),
+		"version": cty.StringVal(version),
+	}))
+	if module.Backend != "" {
+		terraform.Body().AppendNewline()
+		backend := terraform.Body().AppendNewBlock("backend", []string{module.Backend})
+		config := map[string]string{}
+		if module.Backend == backendLocal {
+			config["path"] = "terraform.tfstate"
+		}
+		for key, value := range module.BackendConfig {
+			config[key] = strings.ReplaceAll(value, "{corp}", corp)
+		}
+		var keys []string
+		for key := range config {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			backend.Body().SetAttributeValue(key, backendValue(config[key]))
+		}
+	}
+	providers.Body().AppendNewline()
+
+	provider := providers.Body().AppendNewBlock("provider", []string{"sigsci"})
+	if module.ProviderAlias != "" {
+		provider.Body().SetAttributeValue("alias", cty.StringVal(module.ProviderAlias))
+	}
+	if module.Corp != "" {
+		provider.Body().SetAttributeValue("corp", cty.StringVal(module.Corp))
+	} else {
+		provider.Body().SetAttributeTraversal("corp", varTraversal("NGWAF_CORP"))
+	}
+	provider.Body().SetAttributeTraversal("email", varTraversal("NGWAF_EMAIL"))
+	provider.Body().SetAttributeTraversal("auth_token", varTraversal("NGWAF_TOKEN"))
+
+	if err := write_terraform_config_file(providers, filepath.Join(dir, "providers.tf")); err != nil {
+		return err
+	}
+
+	variables := hclwrite.NewEmptyFile()
+	if module.Corp == "" {
+		corpVariable := variables.Body().AppendNewBlock("variable", []string{"NGWAF_CORP"})
+		corpVariable.Body().SetAttributeTraversal("type", hcl.Traversal{hcl.TraverseRoot{Name: "string"}})
+		corpVariable.Body().SetAttributeValue("description", cty.StringVal("Corp name for NGWAF"))
+		variables.Body().AppendNewline()
+	}
+	suffix := "."
+	if module.Corp != "" {
+		suffix = fmt.Sprintf(" of corp %s.", module.Corp)
+	}
+	email := variables.Body().AppendNewBlock("variable", []string{"NGWAF_EMAIL"})
+	email.Body().SetAttributeTraversal("type", hcl.Traversal{hcl.TraverseRoot{Name: "string"}})
+	email.Body().SetAttributeValue("description", cty.StringVal("Email address associated with the token for the NGWAF API"+suffix))
+	variables.Body().AppendNewline()
+	token := variables.Body().AppendNewBlock("variable", []string{"NGWAF_TOKEN"})
+	token.Body().SetAttributeTraversal("type", hcl.Traversal{hcl.TraverseRoot{Name: "string"}})
+	token.Body().SetAttributeValue("description", cty.StringVal("Secret token for the NGWAF API"+suffix))
+	token.Body().SetAttributeValue("sensitive", cty.True)
+
+	if err := write_terraform_config_file(variables, filepath.Join(dir, "variables.tf")); err != nil {
+		return err
+	}
+
+	return add_gitignore_entries(filepath.Join(dir, ".gitignore"), gitignoreEntries)
+}
+
+// add_gitignore_entries appends the entries missing from the .gitignore at
+// path, creating it if needed
+func add_gitignore_entries(path string, entries []string) error {
+	content, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error reading %s: %v", path, err)
+	}
+
+	existing := map[string]bool{}
+	for _, line := range strings.Split(string(content), "\n") {
+		existing[strings.TrimSpace(line)] = true
+	}
+
+	var missing []string
+	for _, entry := range entries {
+		if !existing[entry] {
+			missing = append(missing, entry)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0666)
+	if err != nil {
+		return fmt.Errorf("error opening %s: %v", path, err)
+	}
+	defer file.Close()
+	if len(content) > 0 && !strings.HasSuffix(string(content), "\n") {
+		missing = append([]string{""}, missing...)
+	}
+	if _, err := file.WriteString(strings.Join(missing, "\n") + "\n"); err != nil {
+		return fmt.Errorf("error writing %s: %v", path, err)
+	}
+	return nil
+}
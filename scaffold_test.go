@@ -0,0 +1,63 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRootModuleGolden(t *testing.T) {
+	dir := t.TempDir()
+
+	opts := RunOptions{
+		Format:          formatHCL,
+		Output:          outputTerraform,
+		ProviderVersion: "3.3.0",
+		Backend:         backendS3,
+		BackendConfig: map[string]string{
+			"bucket":  "acme-terraform",
+			"key":     "ngwaf/{corp}/terraform.tfstate",
+			"region":  "eu-west-1",
+			"encrypt": "true",
+		},
+	}
+	if err := write_corp_root_module(dir, "testcorp", opts, RootModule{}); err != nil {
+		t.Fatal(err)
+	}
+
+	assertGolden(t, filepath.Join(dir, "providers.tf"), filepath.Join("rootmodule", "providers.tf"))
+	assertGolden(t, filepath.Join(dir, "variables.tf"), filepath.Join("rootmodule", "variables.tf"))
+	assertGolden(t, filepath.Join(dir, ".gitignore"), filepath.Join("rootmodule", "gitignore"))
+}
+
+func TestAddGitignoreEntriesKeepsExisting(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".gitignore")
+	if err := os.WriteFile(path, []byte("node_modules\n*.tfstate"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := add_gitignore_entries(path, []string{"*.tfstate", ".terraform/"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "node_modules\n*.tfstate\n.terraform/\n"; string(content) != want {
+		t.Errorf("got %q, want %q", content, want)
+	}
+}
+
+func TestRootModuleSkippedForPrograms(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := write_corp_root_module(dir, "testcorp", RunOptions{Format: formatHCL, Output: outputPulumiYAML}, RootModule{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "providers.tf")); !os.IsNotExist(err) {
+		t.Error("providers.tf written for a Pulumi program")
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// tfVariable is an attribute value read from the named input variable
+type tfVariable string
+
+// secretVariable is a sensitive input variable replacing a secret value
+type secretVariable struct {
+	Name        string
+	Description string
+}
+
+// secretResourceTypes are the resource types whose string attributes are
+// checked for secrets
+var secretResourceTypes = map[string]bool{
+	"sigsci_site_integration": true,
+	"sigsci_site_alert":       true,
+	"sigsci_site_agent_alert": true,
+}
+
+// secretAttributes are always secret, integration URLs embed the webhook
+// credentials
+var secretAttributes = map[string]map[string]bool{
+	"sigsci_site_integration": {"url": true},
+}
+
+// webhookHosts serve webhooks whose URL is the credential
+var webhookHosts = []string{
+	"hooks.slack.com",
+	"webhook.office.com",
+	"outlook.office.com",
+	"discord.com",
+	"discordapp.com",
+	"chat.googleapis.com",
+	"events.pagerduty.com",
+	"api.opsgenie.com",
+}
+
+var secretParamPattern = regexp.MustCompile(`(?i)token|key|secret|sig|password|auth|code`)
+
+// tokenPattern matches bare API tokens: long runs of token characters
+// mixing letters and digits
+var tokenPattern = regexp.MustCompile(`^[A-Za-z0-9_\-]{24,}$`)
+
+// looksSecret reports whether value is a credential or a URL carrying one
+func looksSecret(value string) bool {
+	if u, err := url.Parse(value); err == nil && u.Host != "" {
+		if u.User != nil {
+			return true
+		}
+		for _, host := range webhookHosts {
+			if u.Hostname() == host || strings.HasSuffix(u.Hostname(), "."+host) {
+				return true
+			}
+		}
+		for param := range u.Query() {
+			if secretParamPattern.MatchString(param) {
+				return true
+			}
+		}
+		return false
+	}
+	return tokenPattern.MatchString(value) &&
+		strings.ContainsAny(value, "0123456789") &&
+		strings.IndexFunc(value, unicode.IsLetter) >= 0
+}
+
+// secretVariableName is the name of the variable for attr of target
+func secretVariableName(target ImportTarget, attr string) string {
+	name := fmt.Sprintf("%s_%s_%s", strings.TrimPrefix(target.ResourceType, "sigsci_"), target.Name, attr)
+	return strings.ReplaceAll(name, "-", "_")
+}
+
+// redact_secrets replaces the secret values in config, the configuration of
+// target, with sensitive variables, and returns those variables
+func redact_secrets(target ImportTarget, config *tfBlock) []secretVariable {
+	if !secretResourceTypes[target.ResourceType] {
+		return nil
+	}
+	var secrets []secretVariable
+	for i, attr := range config.Attrs {
+		value, ok := attr.Value.(string)
+		if !ok || value == "" {
+			continue
+		}
+		if !secretAttributes[target.ResourceType][attr.Name] && !looksSecret(value) {
+			continue
+		}
+		variable := secretVariable{
+			Name:        secretVariableName(target, attr.Name),
+			Description: fmt.Sprintf("%s of %s", attr.Name, target.Address()),
+		}
+		if target.ID != "" {
+			variable.Description += fmt.Sprintf(" (%s)", target.ID)
+		}
+		config.Attrs[i].Value = tfVariable(variable.Name)
+		secrets = append(secrets, variable)
+	}
+	return secrets
+}
+
+// secret_target_config is target_config with the secrets replaced by
+// variables, which are appended to secrets
+func secret_target_config(target ImportTarget, secrets *[]secretVariable) (*tfBlock, error) {
+	config, err := target_config(target)
+	if err != nil {
+		return nil, err
+	}
+	*secrets = append(*secrets, redact_secrets(target, config)...)
+	return config, nil
+}
+
+func variableTraversal(name tfVariable) hcl.Traversal {
+	return varTraversal(string(name))
+}
+
+// write_secret_variables writes secrets.tf declaring the sensitive variables
+// and secrets.auto.tfvars.example listing the values to supply. Nothing is
+// written without secrets.
+func write_secret_variables(secrets []secretVariable, dir string) error {
+	if len(secrets) == 0 {
+		return nil
+	}
+
+	file := hclwrite.NewEmptyFile()
+	var example bytes.Buffer
+	example.WriteString("# Values of the sensitive variables in secrets.tf. Copy this file to\n")
+	example.WriteString("# secrets.auto.tfvars, which git ignores, and fill them in.\n")
+	for i, secret := range secrets {
+		if i > 0 {
+			file.Body().AppendNewline()
+		}
+		variable := file.Body().AppendNewBlock("variable", []string{secret.Name}).Body()
+		variable.SetAttributeTraversal("type", hcl.Traversal{hcl.TraverseRoot{Name: "string"}})
+		variable.SetAttributeValue("description", cty.StringVal(secret.Description))
+		variable.SetAttributeValue("sensitive", cty.True)
+
+		fmt.Fprintf(&example, "\n# %s\n%s = \"\"\n", secret.Description, secret.Name)
+	}
+
+	if err := write_terraform_config_file(file, filepath.Join(dir, "secrets.tf")); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "secrets.auto.tfvars.example"), example.Bytes(), 0666); err != nil {
+		return fmt.Errorf("error writing secrets.auto.tfvars.example: %v", err)
+	}
+	return nil
+}
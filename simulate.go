@@ -0,0 +1,544 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	sigsci "github.com/signalsciences/go-sigsci"
+)
+
+// simRequest describes the request simulate evaluates the rules against
+type simRequest struct {
+	Method  string      `json:"method"`
+	Scheme  string      `json:"scheme"`
+	Domain  string      `json:"domain,omitempty"`
+	Path    string      `json:"path"`
+	Query   url.Values  `json:"query,omitempty"`
+	Headers http.Header `json:"headers,omitempty"`
+	IP      string      `json:"ip,omitempty"`
+	Country string      `json:"country,omitempty"`
+	Signals []string    `json:"signals,omitempty"`
+}
+
+// simRule is a corp or site rule as the simulator sees it
+type simRule struct {
+	// Name is the Terraform address, or the ID for rules the tool does
+	// not import
+	Name string
+	Body sigsci.CreateSiteRuleBody
+}
+
+// simMatch is a rule matching the request
+type simMatch struct {
+	Rule    string   `json:"rule"`
+	Type    string   `json:"type"`
+	Reason  string   `json:"reason,omitempty"`
+	Actions []string `json:"actions"`
+}
+
+// simResult is the outcome of a simulation
+type simResult struct {
+	Request  simRequest `json:"request"`
+	Matches  []simMatch `json:"matches"`
+	Signals  []string   `json:"signals"`
+	Decision string     `json:"decision"`
+}
+
+// simulate evaluates rules, in order, against request. lists are the
+// lists in scope by ID. Request rules add signals later rules can match on,
+// signal exclusion rules remove them. An allow wins over a block, as in
+// the WAF; rate limit rules are reported as counting the request.
+func simulate(request simRequest, rules []simRule, lists map[string]sigsci.CreateListBody, blockCode int) simResult {
+	result := simResult{Request: request, Matches: []simMatch{}}
+	signals := map[string]bool{}
+	for _, signal := range request.Signals {
+		signals[signal] = true
+	}
+	eval := simEvaluator{request: request, lists: lists, signals: signals}
+
+	allowed, blocked, challenged := false, 0, false
+	for _, rule := range rules {
+		body := rule.Body
+		if !body.Enabled || !eval.group(body.GroupOperator, body.Conditions) {
+			continue
+		}
+		match := simMatch{Rule: rule.Name, Type: body.Type, Reason: body.Reason}
+		switch body.Type {
+		case "signal":
+			delete(signals, body.Signal)
+			match.Actions = []string{"excludeSignal " + body.Signal}
+		case "templatedSignal":
+			signals[body.Signal] = true
+			match.Actions = []string{"addSignal " + body.Signal}
+		case "rateLimit":
+			description := "counts toward the rate limit"
+			if body.RateLimit != nil {
+				description = fmt.Sprintf("counts toward %d requests in %d minutes", body.RateLimit.Threshold, body.RateLimit.Interval)
+			}
+			match.Actions = append(match.Actions, description)
+			for _, action := range body.Actions {
+				match.Actions = append(match.Actions, action_description(action)+" once exceeded")
+			}
+		default:
+			for _, action := range body.Actions {
+				match.Actions = append(match.Actions, action_description(action))
+				switch action.Type {
+				case "addSignal":
+					signals[action.Signal] = true
+				case "allow":
+					allowed = true
+				case "block":
+					if blocked == 0 {
+						blocked = blockCode
+						if action.ResponseCode != 0 {
+							blocked = action.ResponseCode
+						}
+					}
+				case "browserChallenge":
+					challenged = true
+				}
+			}
+		}
+		result.Matches = append(result.Matches, match)
+	}
+
+	for signal := range signals {
+		result.Signals = append(result.Signals, signal)
+	}
+	sort.Strings(result.Signals)
+	switch {
+	case allowed:
+		result.Decision = "allowed"
+	case blocked != 0:
+		result.Decision = fmt.Sprintf("blocked (%d)", blocked)
+	case challenged:
+		result.Decision = "browser challenge"
+	default:
+		result.Decision = "passed"
+	}
+	return result
+}
+
+func action_description(action sigsci.Action) string {
+	if action.Signal != "" {
+		return action.Type + " " + action.Signal
+	}
+	return action.Type
+}
+
+// simEvaluator evaluates conditions against a request
+type simEvaluator struct {
+	request simRequest
+	lists   map[string]sigsci.CreateListBody
+	signals map[string]bool
+}
+
+// simMultivalFields are the fields holding name and value pairs, matched
+// by multival conditions or, in single conditions, by name
+var simMultivalFields = map[string]bool{"requestHeader": true, "queryParameter": true, "postParameter": true, "requestCookie": true}
+
+func (e simEvaluator) group(operator string, conditions []sigsci.Condition) bool {
+	for _, condition := range conditions {
+		matched := e.condition(condition)
+		if operator == "any" && matched {
+			return true
+		}
+		if operator != "any" && !matched {
+			return false
+		}
+	}
+	return operator != "any" || len(conditions) == 0
+}
+
+func (e simEvaluator) condition(condition sigsci.Condition) bool {
+	switch condition.Type {
+	case "group":
+		return e.group(condition.GroupOperator, condition.Conditions)
+	case "multival":
+		for _, pair := range e.pairs(condition.Field) {
+			if e.pair_group(condition.GroupOperator, condition.Conditions, pair) {
+				return true
+			}
+		}
+		return false
+	}
+	return e.match(condition, e.values(condition.Field))
+}
+
+// pair_group evaluates the conditions of a multival condition against one
+// name and value pair
+func (e simEvaluator) pair_group(operator string, conditions []sigsci.Condition, pair [2]string) bool {
+	for _, condition := range conditions {
+		var values []string
+		switch condition.Field {
+		case "name":
+			values = []string{pair[0]}
+		case "valueString", "valueInt", "valueIp":
+			values = []string{pair[1]}
+		}
+		matched := e.match(condition, values)
+		if operator == "any" && matched {
+			return true
+		}
+		if operator != "any" && !matched {
+			return false
+		}
+	}
+	return operator != "any" || len(conditions) == 0
+}
+
+// pairs are the name and value pairs of a multival field
+func (e simEvaluator) pairs(field string) [][2]string {
+	var pairs [][2]string
+	var source map[string][]string
+	switch field {
+	case "requestHeader":
+		source = e.request.Headers
+	case "queryParameter":
+		source = e.request.Query
+	case "requestCookie":
+		source = map[string][]string{}
+		for _, cookie := range (&http.Request{Header: http.Header{"Cookie": e.request.Headers.Values("Cookie")}}).Cookies() {
+			source[cookie.Name] = append(source[cookie.Name], cookie.Value)
+		}
+	}
+	for name, values := range source {
+		for _, value := range values {
+			pairs = append(pairs, [2]string{name, value})
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i][0] < pairs[j][0] })
+	return pairs
+}
+
+// values are the values of field in the request, none when it has none
+func (e simEvaluator) values(field string) []string {
+	request := e.request
+	var values []string
+	switch field {
+	case "scheme":
+		values = []string{request.Scheme}
+	case "method":
+		values = []string{request.Method}
+	case "path":
+		values = []string{request.Path}
+	case "uri":
+		values = []string{request.Path}
+		if len(request.Query) > 0 {
+			values[0] += "?" + request.Query.Encode()
+		}
+	case "domain":
+		values = []string{request.Domain}
+	case "useragent":
+		values = []string{request.Headers.Get("User-Agent")}
+	case "ip":
+		values = []string{request.IP}
+	case "country":
+		values = []string{request.Country}
+	case "paramname":
+		for name := range request.Query {
+			values = append(values, name)
+		}
+	case "paramvalue":
+		for _, parameter := range request.Query {
+			values = append(values, parameter...)
+		}
+	case "signalType":
+		for signal := range e.signals {
+			values = append(values, signal)
+		}
+	default:
+		if simMultivalFields[field] {
+			for _, pair := range e.pairs(field) {
+				values = append(values, pair[0])
+			}
+		}
+	}
+	var present []string
+	for _, value := range values {
+		if value != "" {
+			present = append(present, value)
+		}
+	}
+	sort.Strings(present)
+	return present
+}
+
+// simNegations are the operators matching when their positive operator
+// matches no value
+var simNegations = map[string]string{
+	"doesNotEqual":   "equals",
+	"doesNotContain": "contains",
+	"notLike":        "like",
+	"doesNotMatch":   "matches",
+	"notInList":      "inList",
+}
+
+// match reports whether a single condition matches any of values
+func (e simEvaluator) match(condition sigsci.Condition, values []string) bool {
+	switch condition.Operator {
+	case "exists":
+		return len(values) > 0
+	case "doesNotExist":
+		return len(values) == 0
+	}
+	if positive, ok := simNegations[condition.Operator]; ok {
+		condition.Operator = positive
+		return !e.match(condition, values)
+	}
+	for _, value := range values {
+		if e.match_value(condition, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e simEvaluator) match_value(condition sigsci.Condition, value string) bool {
+	want := condition.Value
+	if condition.Field == "domain" || condition.Field == "method" || simMultivalFields[condition.Field] || condition.Field == "name" {
+		want, value = strings.ToLower(want), strings.ToLower(value)
+	}
+	switch condition.Operator {
+	case "equals":
+		return value == want
+	case "contains":
+		return strings.Contains(value, want)
+	case "prefix":
+		return strings.HasPrefix(value, want)
+	case "suffix":
+		return strings.HasSuffix(value, want)
+	case "like":
+		return glob_match(want, value)
+	case "matches":
+		pattern, err := regexp.Compile(want)
+		return err == nil && pattern.MatchString(value)
+	case "greaterEqual", "lesserEqual":
+		have, err1 := strconv.ParseFloat(value, 64)
+		limit, err2 := strconv.ParseFloat(want, 64)
+		if err1 != nil || err2 != nil {
+			return false
+		}
+		return condition.Operator == "greaterEqual" && have >= limit || condition.Operator == "lesserEqual" && have <= limit
+	case "inList":
+		list, ok := e.lists[condition.Value]
+		return ok && list_contains(list, value)
+	}
+	return false
+}
+
+// list_contains reports whether value is in list, by the rules of its
+// type: addresses in CIDRs, wildcards as globs, countries ignoring case
+func list_contains(list sigsci.CreateListBody, value string) bool {
+	for _, entry := range list.Entries {
+		switch list.Type {
+		case "ip":
+			if _, network, err := net.ParseCIDR(entry); err == nil {
+				if ip := net.ParseIP(value); ip != nil && network.Contains(ip) {
+					return true
+				}
+			} else if ip, entryIP := net.ParseIP(value), net.ParseIP(entry); ip != nil && ip.Equal(entryIP) {
+				return true
+			}
+		case "wildcard":
+			if glob_match(entry, value) {
+				return true
+			}
+		case "country":
+			if strings.EqualFold(entry, value) {
+				return true
+			}
+		default:
+			if entry == value {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// glob_match matches value against pattern, where * matches anything
+func glob_match(pattern string, value string) bool {
+	parts := strings.Split(pattern, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	return regexp.MustCompile("^" + strings.Join(parts, ".*") + "$").MatchString(value)
+}
+
+// snapshot_simulation returns the rules of site in snapshot, corp rules
+// applying to it first, and the lists they can reference
+func snapshot_simulation(snapshot CorpSnapshot, siteName string) ([]simRule, map[string]sigsci.CreateListBody, int, error) {
+	names := map[string]string{}
+	for _, target := range import_set_from_snapshot(snapshot, nil).Targets {
+		names[target.ID] = target.Address()
+	}
+	name := func(id string) string {
+		if address, ok := names[id]; ok {
+			return address
+		}
+		return id
+	}
+
+	var site *SiteSnapshot
+	for i := range snapshot.Sites {
+		if snapshot.Sites[i].Site.Name == siteName {
+			site = &snapshot.Sites[i]
+		}
+	}
+	if site == nil {
+		return nil, nil, 0, fmt.Errorf("corp %s has no site %s", snapshot.Corp, siteName)
+	}
+
+	var rules []simRule
+	for _, rule := range snapshot.Rules.Data {
+		if rule.CorpScope == "global" || slices.Contains(rule.SiteNames, siteName) {
+			rules = append(rules, simRule{Name: name(rule.ID), Body: corp_rule_as_site_rule(rule.CreateCorpRuleBody)})
+		}
+	}
+	for _, rule := range site.Rules.Data {
+		rules = append(rules, simRule{Name: name(siteName + ":" + rule.ID), Body: rule.CreateSiteRuleBody})
+	}
+	lists := map[string]sigsci.CreateListBody{}
+	for _, list := range snapshot.Lists.Data {
+		lists[list.ID] = list.CreateListBody
+	}
+	for _, list := range site.Lists.Data {
+		lists[list.ID] = list.CreateListBody
+	}
+	return rules, lists, site.Site.BlockHTTPCode, nil
+}
+
+// config_simulation returns the rules of site in decoded configuration,
+// corp rules applying to it first, and the lists they can reference, by
+// the ID of their import block and the ID the API derives from their name
+func config_simulation(resources []apiResource, siteName string) ([]simRule, map[string]sigsci.CreateListBody, error) {
+	var corpRules, siteRules []simRule
+	lists := map[string]sigsci.CreateListBody{}
+	for _, resource := range resources {
+		if resource.Err != nil {
+			return nil, nil, resource.Err
+		}
+		switch body := resource.Body.(type) {
+		case sigsci.CreateCorpRuleBody:
+			if body.CorpScope == "global" || slices.Contains(body.SiteNames, siteName) {
+				corpRules = append(corpRules, simRule{Name: resource.Address, Body: corp_rule_as_site_rule(body)})
+			}
+		case sigsci.CreateSiteRuleBody:
+			if resource.Site == siteName {
+				siteRules = append(siteRules, simRule{Name: resource.Address, Body: body})
+			}
+		case sigsci.CreateListBody:
+			if resource.Site != "" && resource.Site != siteName {
+				continue
+			}
+			lists[derived_id(resource.Type, body.Name)] = body
+			if resource.ImportID != "" {
+				lists[object_id(resource)] = body
+			}
+		}
+	}
+	return append(corpRules, siteRules...), lists, nil
+}
+
+// headersFlag collects repeated "Name: value" flags
+type headersFlag http.Header
+
+func (f headersFlag) String() string {
+	return fmt.Sprint(http.Header(f))
+}
+
+func (f headersFlag) Set(header string) error {
+	name, value, ok := strings.Cut(header, ":")
+	if !ok || strings.TrimSpace(name) == "" {
+		return fmt.Errorf("expected \"Name: value\", got %q", header)
+	}
+	http.Header(f).Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	return nil
+}
+
+func run_simulate(ctx context.Context, sc sigsci.Client, api *APIClient, args []string) error {
+	flags := flag.NewFlagSet("simulate", flag.ExitOnError)
+	corp := flags.String("corp", os.Getenv("TF_VAR_NGWAF_CORP"), "corp to fetch the rules from, without configuration files")
+	site := flags.String("site", "", "site receiving the request")
+	request := simRequest{Headers: http.Header{}}
+	flags.StringVar(&request.Method, "method", "GET", "request method")
+	flags.StringVar(&request.Scheme, "scheme", "https", "request scheme")
+	flags.StringVar(&request.Path, "path", "/", "request path")
+	query := flags.String("query", "", "query string, a=1&b=2")
+	flags.Var(headersFlag(request.Headers), "header", "request header as \"Name: value\", repeatable")
+	flags.StringVar(&request.IP, "ip", "", "client IP address")
+	flags.StringVar(&request.Country, "country", "", "client country code")
+	flags.Var((*stringsFlag)(&request.Signals), "signal", "signal the request already has, e.g. from a detection, repeatable")
+	format := flags.String("format", "text", "output format: text or json")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: simulate -site site [request flags] [file.tf ...]")
+		fmt.Fprintln(flags.Output(), "Evaluates the rules of the configuration files, or without files the rules fetched from the corp.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if *site == "" {
+		flags.Usage()
+		return fmt.Errorf("simulate needs -site")
+	}
+	values, err := url.ParseQuery(*query)
+	if err != nil {
+		return fmt.Errorf("invalid -query: %v", err)
+	}
+	request.Query = values
+	request.Method = strings.ToUpper(request.Method)
+	request.Domain = request.Headers.Get("Host")
+
+	var rules []simRule
+	var lists map[string]sigsci.CreateListBody
+	blockCode := 406
+	if flags.NArg() == 0 {
+		snapshot, err := fetch_corp_snapshot(ctx, sc, api, *corp)
+		if err != nil {
+			return err
+		}
+		if rules, lists, blockCode, err = snapshot_simulation(snapshot, *site); err != nil {
+			return err
+		}
+	} else {
+		files, err := read_config_files(flags.Args())
+		if err != nil {
+			return err
+		}
+		resources, err := decode_resources(files, filepath.Dir(flags.Arg(0)), nil)
+		if err != nil {
+			return err
+		}
+		if rules, lists, err = config_simulation(resources, *site); err != nil {
+			return err
+		}
+	}
+
+	result := simulate(request, rules, lists, blockCode)
+	if *format == "json" {
+		content, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(content))
+		return nil
+	}
+	for _, match := range result.Matches {
+		fmt.Printf("match %s (%s): %s\n", match.Rule, match.Reason, strings.Join(match.Actions, ", "))
+	}
+	fmt.Println("signals:", strings.Join(result.Signals, ", "))
+	fmt.Println("decision:", result.Decision)
+	return nil
+}
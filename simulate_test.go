@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	sigsci "github.com/signalsciences/go-sigsci"
+)
+
+// simulateCases run against site www of the testcorp fixture, unless noted
+var simulateCases = []struct {
+	name     string
+	site     string
+	request  simRequest
+	matches  []string
+	signals  []string
+	decision string
+	// fetchedOnly cases need rules the tool does not import
+	fetchedOnly bool
+}{
+	{
+		name:     "admin from the office",
+		request:  simRequest{Method: "GET", Path: "/admin/users", IP: "192.0.2.10"},
+		matches:  []string{"sigsci_site_rule." + sanitizeTfId("61b2c3d4e5f60718293a4b5c")},
+		decision: "blocked (406)",
+	},
+	{
+		name:    "post from a blocked address",
+		request: simRequest{Method: "POST", Path: "/api", IP: "198.51.100.20"},
+		matches: []string{
+			"sigsci_corp_rule." + sanitizeTfId("60a1b2c3d4e5f60718293a4b"),
+			"sigsci_site_rule." + sanitizeTfId("61b2c3d4e5f60718293a4b5c"),
+			"sigsci_site_rule." + sanitizeTfId("61b2c3d4e5f60718293a4b60"),
+		},
+		signals:  []string{"corp.bad-bot"},
+		decision: "blocked (406)",
+	},
+	{
+		name:        "health check with an excluded signal",
+		request:     simRequest{Method: "GET", Path: "/healthz", IP: "192.0.2.10", Signals: []string{"SQLI"}},
+		matches:     []string{"60a1b2c3d4e5f60718293a4c"},
+		decision:    "passed",
+		fetchedOnly: true,
+	},
+	{
+		name:    "login",
+		request: simRequest{Method: "POST", Path: "/login", IP: "192.0.2.10"},
+		matches: []string{
+			"sigsci_site_rule." + sanitizeTfId("61b2c3d4e5f60718293a4b5d"),
+			"sigsci_site_rule." + sanitizeTfId("61b2c3d4e5f60718293a4b5e"),
+		},
+		signals:  []string{"LOGINATTEMPT"},
+		decision: "passed",
+	},
+	{
+		name:     "partner",
+		site:     "api2",
+		request:  simRequest{Method: "GET", Path: "/", IP: "192.0.2.10", Headers: http.Header{"X-Partner": {"acme"}}},
+		matches:  []string{"sigsci_site_rule." + sanitizeTfId("66a718293a4b5c6d7e8f9012")},
+		decision: "allowed",
+	},
+}
+
+func TestSimulate(t *testing.T) {
+	_, sc, api := newFakeAPI(t, "testcorp")
+	snapshot, err := fetch_corp_snapshot(context.Background(), sc, api, "testcorp")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The same requests against the compact configuration the tool renders
+	outputDir := t.TempDir()
+	opts := RunOptions{Format: formatHCL, Output: outputTerraform, Compact: true, ListFiles: listFilesTxt}
+	if err := terraformify_corp(context.Background(), sc, api, "testcorp", outputDir, opts, ""); err != nil {
+		t.Fatal(err)
+	}
+	files, err := read_config_files([]string{filepath.Join(outputDir, "import.tf")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TF_VAR_site_integration_"+sanitizeTfId("62c3d4e5f60718293a4b5c6d")+"_url", "https://hooks.example.com/x")
+	resources, err := decode_resources(files, outputDir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range simulateCases {
+		site := c.site
+		if site == "" {
+			site = "www"
+		}
+		c.request.Scheme = "https"
+		c.request.Query = url.Values{}
+		if c.request.Headers == nil {
+			c.request.Headers = http.Header{}
+		}
+
+		rules, lists, blockCode, err := snapshot_simulation(snapshot, site)
+		if err != nil {
+			t.Fatal(err)
+		}
+		check_simulation(t, c.name+", fetched", simulate(c.request, rules, lists, blockCode), c.matches, c.signals, c.decision)
+		if c.fetchedOnly {
+			continue
+		}
+
+		rules, lists, err = config_simulation(resources, site)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var matches []string
+		for _, match := range c.matches {
+			matches = append(matches, compact_address(match))
+		}
+		check_simulation(t, c.name+", rendered", simulate(c.request, rules, lists, 406), matches, c.signals, c.decision)
+	}
+}
+
+// compact_address is the address of a resource in the compact output
+func compact_address(address string) string {
+	resourceType, name, _ := strings.Cut(address, ".")
+	return fmt.Sprintf("%s.%s[%q]", resourceType, compactResourceName, name)
+}
+
+func check_simulation(t *testing.T, name string, result simResult, matches []string, signals []string, decision string) {
+	t.Helper()
+	var got []string
+	for _, match := range result.Matches {
+		got = append(got, match.Rule)
+	}
+	if !reflect.DeepEqual(got, matches) {
+		t.Errorf("%s: matches are %v, want %v", name, got, matches)
+	}
+	if !reflect.DeepEqual(result.Signals, signals) {
+		t.Errorf("%s: signals are %v, want %v", name, result.Signals, signals)
+	}
+	if result.Decision != decision {
+		t.Errorf("%s: decision is %s, want %s", name, result.Decision, decision)
+	}
+}
+
+func TestListContains(t *testing.T) {
+	for _, c := range []struct {
+		listType string
+		entries  []string
+		value    string
+		want     bool
+	}{
+		{"ip", []string{"198.51.100.0/24"}, "198.51.100.7", true},
+		{"ip", []string{"198.51.100.0/24"}, "198.51.101.7", false},
+		{"ip", []string{"2001:db8::1"}, "2001:0db8::1", true},
+		{"wildcard", []string{"*.example.com"}, "www.example.com", true},
+		{"wildcard", []string{"*.example.com"}, "example.com", false},
+		{"country", []string{"de"}, "DE", true},
+		{"string", []string{"abc"}, "ABC", false},
+	} {
+		list := sigsci.CreateListBody{Type: c.listType, Entries: c.entries}
+		if got := list_contains(list, c.value); got != c.want {
+			t.Errorf("%s list %v contains %s: %v, want %v", c.listType, c.entries, c.value, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	sigsci "github.com/signalsciences/go-sigsci"
+)
+
+// CorpSnapshot holds everything the tool discovers in a corp
+type CorpSnapshot struct {
+	Corp    string                           `json:"corp"`
+	Rules   sigsci.ResponseCorpRuleBodyList  `json:"rules"`
+	Lists   sigsci.ResponseListBodyList      `json:"lists"`
+	Signals sigsci.ResponseSignalTagBodyList `json:"signals"`
+	Sites   []SiteSnapshot                   `json:"sites"`
+}
+
+// SiteSnapshot holds everything the tool discovers in a site
+type SiteSnapshot struct {
+	Site                 sigsci.Site                             `json:"site"`
+	Rules                sigsci.ResponseSiteRuleBodyList         `json:"rules"`
+	LegacyTemplatedRules ResponseSiteLegacyTemplatedRuleBodyList `json:"legacyTemplatedRules"`
+	Signals              sigsci.ResponseSignalTagBodyList        `json:"signals"`
+	Lists                sigsci.ResponseListBodyList             `json:"lists"`
+	Integrations         []sigsci.Integration                    `json:"integrations"`
+	HeaderLinks          []sigsci.HeaderLink                     `json:"headerLinks"`
+	Alerts               []sigsci.CustomAlert                    `json:"alerts"`
+}
+
+// fetch_corp_snapshot fetches every object of corp. The deprecated
+// configured templates endpoint is allowed to fail, anything else aborts.
+func fetch_corp_snapshot(ctx context.Context, sc sigsci.Client, api *APIClient, corp string) (CorpSnapshot, error) {
+	snapshot := CorpSnapshot{Corp: corp}
+	var err error
+
+	if snapshot.Rules, err = sc.GetAllCorpRules(corp); err != nil {
+		return snapshot, fmt.Errorf("error fetching corp rules: %v", err)
+	}
+	if snapshot.Lists, err = sc.GetAllCorpLists(corp); err != nil {
+		return snapshot, fmt.Errorf("error fetching corp lists: %v", err)
+	}
+	if snapshot.Signals, err = sc.GetAllCorpSignalTags(corp); err != nil {
+		return snapshot, fmt.Errorf("error fetching corp signals: %v", err)
+	}
+	sites, err := sc.ListSites(corp)
+	if err != nil {
+		return snapshot, fmt.Errorf("error fetching sites: %v", err)
+	}
+
+	for _, ngwafSite := range sites {
+		if ctx.Err() != nil {
+			return snapshot, fmt.Errorf("interrupted: %v", ctx.Err())
+		}
+
+		site := SiteSnapshot{Site: ngwafSite}
+		if site.Rules, err = sc.GetAllSiteRules(corp, ngwafSite.Name); err != nil {
+			return snapshot, fmt.Errorf("error fetching rules of site %s: %v", ngwafSite.Name, err)
+		}
+		if site.LegacyTemplatedRules, err = get_active_legacy_templated_rules(ctx, api, corp, ngwafSite.Name); err != nil {
+			fmt.Println(err)
+		}
+		if site.Signals, err = sc.GetAllSiteSignalTags(corp, ngwafSite.Name); err != nil {
+			return snapshot, fmt.Errorf("error fetching signals of site %s: %v", ngwafSite.Name, err)
+		}
+		if site.Lists, err = sc.GetAllSiteLists(corp, ngwafSite.Name); err != nil {
+			return snapshot, fmt.Errorf("error fetching lists of site %s: %v", ngwafSite.Name, err)
+		}
+		if site.Integrations, err = sc.ListIntegrations(corp, ngwafSite.Name); err != nil {
+			return snapshot, fmt.Errorf("error fetching integrations of site %s: %v", ngwafSite.Name, err)
+		}
+		if site.HeaderLinks, err = sc.ListHeaderLinks(corp, ngwafSite.Name); err != nil {
+			return snapshot, fmt.Errorf("error fetching header links of site %s: %v", ngwafSite.Name, err)
+		}
+		if site.Alerts, err = sc.ListCustomAlerts(corp, ngwafSite.Name); err != nil {
+			return snapshot, fmt.Errorf("error fetching alerts of site %s: %v", ngwafSite.Name, err)
+		}
+		snapshot.Sites = append(snapshot.Sites, site)
+	}
+
+	return snapshot, nil
+}
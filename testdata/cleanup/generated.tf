@@ -0,0 +1,76 @@
+# __generated__ by Terraform
+# Please review these resources and move them into your main configuration files.
+
+# __generated__ by Terraform from "www"
+resource "sigsci_site" "www" {
+  agent_anon_mode        = null
+  agent_level            = "block"
+  block_duration_seconds = 86400
+  block_http_code        = 406
+  block_redirect_url     = null
+  display_name           = "www"
+  short_name             = "www"
+  primary_agent_key {
+  }
+}
+
+# __generated__ by Terraform from "www:61b2c3d4e5f60718293a4b5c"
+resource "sigsci_site_rule" "GBbCcDdEeFfGAHBICJDaEbFc" {
+  enabled         = true
+  expiration      = ""
+  group_operator  = "all"
+  reason          = "Block bad IPs"
+  requestlogging  = "sampled"
+  signal          = null
+  site_short_name = "www"
+  type            = "request"
+  actions {
+    allow_interactive = false
+    redirect_url      = null
+    response_code     = null
+    signal            = null
+    type              = "block"
+  }
+  conditions {
+    field          = "ip"
+    group_operator = null
+    operator       = "inList"
+    type           = "single"
+    value          = "site.bad-ips"
+  }
+  rate_limit {
+  }
+}
+
+# __generated__ by Terraform from "www:bad-bot"
+resource "sigsci_site_signal_tag" "wwwbad-bot" {
+  configurable    = false
+  description     = "Bad bots"
+  id              = "www:site.bad-bot"
+  informational   = false
+  name            = "bad-bot"
+  needs_response  = false
+  site_short_name = "www"
+}
+
+# __generated__ by Terraform from "www:62c3d4e5f60718293a4b5c6d"
+resource "sigsci_site_integration" "www62c3d4e5f60718293a4b5c6d" {
+  events          = ["listCreated", "listUpdated"]
+  id              = "62c3d4e5f60718293a4b5c6d"
+  site_short_name = "www"
+  type            = "slack"
+  url             = "https://hooks.slack.com/services/T000/B000/XXXXXXXX"
+}
+
+# __generated__ by Terraform from "www:63d4e5f60718293a4b5c6d7e"
+resource "sigsci_site_alert" "www63d4e5f60718293a4b5c6d7e" {
+  action                 = "info"
+  block_duration_seconds = null
+  enabled                = true
+  interval               = 10
+  long_name              = "Bad bot surge"
+  site_short_name        = "www"
+  skip_notifications     = false
+  tag_name               = "bad-bot"
+  threshold              = 50
+}
@@ -0,0 +1,59 @@
+# __generated__ by Terraform
+# Please review these resources and move them into your main configuration files.
+
+# __generated__ by Terraform from "www"
+resource "sigsci_site" "www" {
+  short_name             = "www"
+  display_name           = "www"
+  agent_level            = "block"
+  block_http_code        = 406
+  block_duration_seconds = 86400
+}
+
+# __generated__ by Terraform from "www:61b2c3d4e5f60718293a4b5c"
+resource "sigsci_site_rule" "GBbCcDdEeFfGAHBICJDaEbFc" {
+  site_short_name = "www"
+  type            = "request"
+  group_operator  = "all"
+  enabled         = true
+  reason          = "Block bad IPs"
+  expiration      = ""
+  requestlogging  = "sampled"
+  actions {
+    allow_interactive = false
+    type              = "block"
+  }
+  conditions {
+    field    = "ip"
+    operator = "inList"
+    type     = "single"
+    value    = "site.bad-ips"
+  }
+}
+
+# __generated__ by Terraform from "www:bad-bot"
+resource "sigsci_site_signal_tag" "wwwbad-bot" {
+  site_short_name = "www"
+  name            = "bad-bot"
+  description     = "Bad bots"
+}
+
+# __generated__ by Terraform from "www:62c3d4e5f60718293a4b5c6d"
+resource "sigsci_site_integration" "www62c3d4e5f60718293a4b5c6d" {
+  site_short_name = "www"
+  type            = "slack"
+  url             = var.site_integration_www62c3d4e5f60718293a4b5c6d_url
+  events          = ["listCreated", "listUpdated"]
+}
+
+# __generated__ by Terraform from "www:63d4e5f60718293a4b5c6d7e"
+resource "sigsci_site_alert" "www63d4e5f60718293a4b5c6d7e" {
+  site_short_name    = "www"
+  tag_name           = "bad-bot"
+  long_name          = "Bad bot surge"
+  interval           = 10
+  threshold          = 50
+  enabled            = true
+  action             = "info"
+  skip_notifications = false
+}
@@ -0,0 +1,5 @@
+variable "site_integration_www62c3d4e5f60718293a4b5c6d_url" {
+  type        = string
+  description = "url of sigsci_site_integration.www62c3d4e5f60718293a4b5c6d (www:62c3d4e5f60718293a4b5c6d)"
+  sensitive   = true
+}
@@ -0,0 +1,5 @@
+import {
+  id       = "www"
+  to       = sigsci_site.www
+  provider = sigsci.staging
+}
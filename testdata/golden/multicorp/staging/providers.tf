@@ -0,0 +1,15 @@
+terraform {
+  required_providers {
+    sigsci = {
+      source  = "signalsciences/sigsci"
+      version = ">= 3.0.1"
+    }
+  }
+}
+
+provider "sigsci" {
+  alias      = "staging"
+  corp       = "stagingcorp"
+  email      = var.NGWAF_EMAIL
+  auth_token = var.NGWAF_TOKEN
+}
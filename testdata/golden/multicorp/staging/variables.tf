@@ -0,0 +1,10 @@
+variable "NGWAF_EMAIL" {
+  type        = string
+  description = "Email address associated with the token for the NGWAF API of corp stagingcorp."
+}
+
+variable "NGWAF_TOKEN" {
+  type        = string
+  description = "Secret token for the NGWAF API of corp stagingcorp."
+  sensitive   = true
+}
@@ -0,0 +1,172 @@
+resource "sigsci_site_list" "www-prodsitedotoffice-ips" {
+  site_short_name = "www-prod"
+  name            = "Office IPs"
+  type            = "ip"
+  description     = "Office egress addresses"
+  entries         = ["192.0.2.10", "192.0.2.11"]
+  provider        = sigsci.prod
+}
+
+resource "sigsci_site_signal_tag" "www-prodsitedotlogin-attempt" {
+  site_short_name = "www-prod"
+  name            = "login-attempt"
+  description     = "Login attempts"
+  provider        = sigsci.prod
+}
+
+resource "sigsci_site_rule" "GBbCcDdEeFfGAHBICJDaEbFc" {
+  site_short_name = "www-prod"
+  type            = "request"
+  group_operator  = "any"
+  enabled         = true
+  reason          = "Block admin from outside the office"
+  conditions {
+    type     = "single"
+    field    = "path"
+    operator = "prefix"
+    value    = "/admin"
+  }
+  conditions {
+    type           = "group"
+    group_operator = "all"
+    conditions {
+      type     = "single"
+      field    = "ip"
+      operator = "notInList"
+      value    = sigsci_site_list.www-prodsitedotoffice-ips.id
+    }
+    conditions {
+      type     = "single"
+      field    = "method"
+      operator = "equals"
+      value    = "POST"
+    }
+  }
+  actions {
+    type = "block"
+  }
+  provider = sigsci.prod
+}
+
+resource "sigsci_site_rule" "GBbCcDdEeFfGAHBICJDaEbFd" {
+  site_short_name = "www-prod"
+  type            = "rateLimit"
+  group_operator  = "all"
+  enabled         = true
+  reason          = "Login rate limit"
+  signal          = sigsci_site_signal_tag.www-prodsitedotlogin-attempt.id
+  conditions {
+    type     = "single"
+    field    = "path"
+    operator = "equals"
+    value    = "/login"
+  }
+  actions {
+    type   = "logRequest"
+    signal = sigsci_site_signal_tag.www-prodsitedotlogin-attempt.id
+  }
+  rate_limit {
+    threshold = 10
+    interval  = 1
+    duration  = 600
+    client_identifiers {
+      type = "ip"
+    }
+  }
+  provider = sigsci.prod
+}
+
+resource "sigsci_site_rule" "GBbCcDdEeFfGAHBICJDaEbFe" {
+  site_short_name = "www-prod"
+  type            = "templatedSignal"
+  group_operator  = "all"
+  enabled         = true
+  reason          = ""
+  signal          = "LOGINATTEMPT"
+  conditions {
+    type     = "single"
+    field    = "path"
+    operator = "equals"
+    value    = "/login"
+  }
+  actions {
+    type   = "addSignal"
+    signal = "LOGINATTEMPT"
+  }
+  provider = sigsci.prod
+}
+
+resource "sigsci_site_rule" "GBbCcDdEeFfGAHBICJDaEbFf" {
+  site_short_name = "www-prod"
+  type            = "signal"
+  group_operator  = "all"
+  enabled         = false
+  reason          = "Old exclusion"
+  signal          = "XSS"
+  conditions {
+    type     = "single"
+    field    = "path"
+    operator = "equals"
+    value    = "/legacy"
+  }
+  actions {
+    type = "excludeSignal"
+  }
+  provider = sigsci.prod
+}
+
+resource "sigsci_site_rule" "GBbCcDdEeFfGAHBICJDaEbGA" {
+  site_short_name = "www-prod"
+  type            = "request"
+  group_operator  = "all"
+  enabled         = true
+  reason          = "Tag traffic from blocked IPs"
+  conditions {
+    type     = "single"
+    field    = "ip"
+    operator = "inList"
+    value    = "corp.blocked-ip-addresses"
+  }
+  actions {
+    type   = "addSignal"
+    signal = "corp.bad-bot"
+  }
+  provider = sigsci.prod
+}
+
+resource "sigsci_site_alert" "GEeFfGAHBICJDaEbFcGdHeIf" {
+  site_short_name    = "www-prod"
+  tag_name           = sigsci_site_signal_tag.www-prodsitedotlogin-attempt.id
+  long_name          = "Too many logins"
+  interval           = 10
+  threshold          = 50
+  enabled            = true
+  action             = "info"
+  skip_notifications = false
+  provider           = sigsci.prod
+}
+
+resource "sigsci_site_agent_alert" "GEeFfGAHBICJDaEbFcGdHeJA" {
+  site_short_name    = "www-prod"
+  tag_name           = "requests_total"
+  long_name          = "Agent request spike"
+  interval           = 5
+  threshold          = 1000
+  enabled            = true
+  action             = "siteMetricInfo"
+  skip_notifications = true
+  provider           = sigsci.prod
+}
+
+resource "sigsci_site_alert" "GEeFfGAHBICJDaEbFcGdHeJB" {
+  site_short_name    = "www-prod"
+  tag_name           = "SQLI"
+  long_name          = "SQLi attack"
+  interval           = 1
+  threshold          = 10
+  enabled            = true
+  action             = "flagged"
+  skip_notifications = false
+  provider           = sigsci.prod
+}
+
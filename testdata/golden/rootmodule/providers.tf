@@ -0,0 +1,21 @@
+terraform {
+  required_providers {
+    sigsci = {
+      source  = "signalsciences/sigsci"
+      version = "3.3.0"
+    }
+  }
+
+  backend "s3" {
+    bucket  = "acme-terraform"
+    encrypt = true
+    key     = "ngwaf/testcorp/terraform.tfstate"
+    region  = "eu-west-1"
+  }
+}
+
+provider "sigsci" {
+  corp       = var.NGWAF_CORP
+  email      = var.NGWAF_EMAIL
+  auth_token = var.NGWAF_TOKEN
+}
@@ -0,0 +1,15 @@
+variable "NGWAF_CORP" {
+  type        = string
+  description = "Corp name for NGWAF"
+}
+
+variable "NGWAF_EMAIL" {
+  type        = string
+  description = "Email address associated with the token for the NGWAF API."
+}
+
+variable "NGWAF_TOKEN" {
+  type        = string
+  description = "Secret token for the NGWAF API."
+  sensitive   = true
+}
@@ -0,0 +1,325 @@
+// Generated by ngwaf-terraformify. Run `cdktf get` to generate the provider bindings.
+package main
+
+import (
+	"github.com/aws/constructs-go/constructs/v10"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/hashicorp/terraform-cdk-go/cdktf"
+
+	"cdk.tf/go/stack/generated/signalsciences/sigsci/corplist"
+	"cdk.tf/go/stack/generated/signalsciences/sigsci/corprule"
+	"cdk.tf/go/stack/generated/signalsciences/sigsci/corpsignaltag"
+	"cdk.tf/go/stack/generated/signalsciences/sigsci/provider"
+	"cdk.tf/go/stack/generated/signalsciences/sigsci/site"
+	"cdk.tf/go/stack/generated/signalsciences/sigsci/siteagentalert"
+	"cdk.tf/go/stack/generated/signalsciences/sigsci/sitealert"
+	"cdk.tf/go/stack/generated/signalsciences/sigsci/siteheaderlink"
+	"cdk.tf/go/stack/generated/signalsciences/sigsci/siteintegration"
+	"cdk.tf/go/stack/generated/signalsciences/sigsci/sitelist"
+	"cdk.tf/go/stack/generated/signalsciences/sigsci/siterule"
+	"cdk.tf/go/stack/generated/signalsciences/sigsci/sitesignaltag"
+	"cdk.tf/go/stack/generated/signalsciences/sigsci/sitetemplatedrule"
+)
+
+func NewNgwafStack(scope constructs.Construct, id string) cdktf.TerraformStack {
+	stack := cdktf.NewTerraformStack(scope, &id)
+
+	ngwafEmail := cdktf.NewTerraformVariable(stack, jsii.String("NGWAF_EMAIL"), &cdktf.TerraformVariableConfig{Type: jsii.String("string")})
+	ngwafToken := cdktf.NewTerraformVariable(stack, jsii.String("NGWAF_TOKEN"), &cdktf.TerraformVariableConfig{Type: jsii.String("string"), Sensitive: jsii.Bool(true)})
+
+	provider.NewSigsciProvider(stack, jsii.String("sigsci"), &provider.SigsciProviderConfig{
+		Corp:      jsii.String("testcorp"),
+		Email:     ngwafEmail.StringValue(),
+		AuthToken: ngwafToken.StringValue(),
+	})
+
+	corprule.NewCorpRule(stack, jsii.String("GAaBbCcDdEeFfGAHBICJDaEb"), &corprule.CorpRuleConfig{
+		SiteShortNames: jsii.Strings(),
+		Type:           jsii.String("request"),
+		CorpScope:      jsii.String("global"),
+		GroupOperator:  jsii.String("all"),
+		Enabled:        jsii.Bool(true),
+		Reason:         jsii.String("Block known bad IPs"),
+		Conditions: &[]*corprule.CorpRuleConditions{
+			{
+				Type:     jsii.String("single"),
+				Field:    jsii.String("ip"),
+				Operator: jsii.String("inList"),
+				Value:    jsii.String("corp.blocked-ips"),
+			},
+		},
+		Actions: &[]*corprule.CorpRuleActions{
+			{
+				Type: jsii.String("block"),
+			},
+		},
+	}).ImportFrom(jsii.String("60a1b2c3d4e5f60718293a4b"), nil)
+
+	corplist.NewCorpList(stack, jsii.String("corpdotblocked-ips"), &corplist.CorpListConfig{
+		Name:        jsii.String("Blocked IPs"),
+		Type:        jsii.String("ip"),
+		Description: jsii.String("Known bad actors"),
+		Entries:     jsii.Strings("203.0.113.7", "198.51.100.0/24", "192.0.2.1"),
+	}).ImportFrom(jsii.String("corp.blocked-ips"), nil)
+
+	corpsignaltag.NewCorpSignalTag(stack, jsii.String("corpdotbad-bot"), &corpsignaltag.CorpSignalTagConfig{
+		ShortName:   jsii.String("bad-bot"),
+		Description: jsii.String("Known bad bots"),
+	}).ImportFrom(jsii.String("corp.bad-bot"), nil)
+
+	site.NewSite(stack, jsii.String("www"), &site.SiteConfig{
+		ShortName:            jsii.String("www"),
+		DisplayName:          jsii.String("Main website"),
+		AgentLevel:           jsii.String("block"),
+		BlockHttpCode:        jsii.Number(406),
+		BlockDurationSeconds: jsii.Number(86400),
+	}).ImportFrom(jsii.String("www"), nil)
+
+	site.NewSite(stack, jsii.String("apiC"), &site.SiteConfig{
+		ShortName:            jsii.String("api2"),
+		DisplayName:          jsii.String("API v2"),
+		AgentLevel:           jsii.String("log"),
+		AgentAnonMode:        jsii.String("EU"),
+		BlockHttpCode:        jsii.Number(406),
+		BlockDurationSeconds: jsii.Number(86400),
+		ClientIpRules: &[]*site.SiteClientIpRules{
+			{
+				Header: jsii.String("X-Forwarded-For"),
+			},
+		},
+	}).ImportFrom(jsii.String("api2"), nil)
+
+	siterule.NewSiteRule(stack, jsii.String("GBbCcDdEeFfGAHBICJDaEbFc"), &siterule.SiteRuleConfig{
+		SiteShortName: jsii.String("www"),
+		Type:          jsii.String("request"),
+		GroupOperator: jsii.String("any"),
+		Enabled:       jsii.Bool(true),
+		Reason:        jsii.String("Block admin from outside the office"),
+		Conditions: &[]*siterule.SiteRuleConditions{
+			{
+				Type:     jsii.String("single"),
+				Field:    jsii.String("path"),
+				Operator: jsii.String("prefix"),
+				Value:    jsii.String("/admin"),
+			},
+			{
+				Type:          jsii.String("group"),
+				GroupOperator: jsii.String("all"),
+				Conditions: &[]*siterule.SiteRuleConditionsConditions{
+					{
+						Type:     jsii.String("single"),
+						Field:    jsii.String("ip"),
+						Operator: jsii.String("notInList"),
+						Value:    jsii.String("site.office-ips"),
+					},
+					{
+						Type:     jsii.String("single"),
+						Field:    jsii.String("method"),
+						Operator: jsii.String("equals"),
+						Value:    jsii.String("POST"),
+					},
+				},
+			},
+		},
+		Actions: &[]*siterule.SiteRuleActions{
+			{
+				Type: jsii.String("block"),
+			},
+		},
+	}).ImportFrom(jsii.String("www:61b2c3d4e5f60718293a4b5c"), nil)
+
+	siterule.NewSiteRule(stack, jsii.String("GBbCcDdEeFfGAHBICJDaEbFd"), &siterule.SiteRuleConfig{
+		SiteShortName: jsii.String("www"),
+		Type:          jsii.String("rateLimit"),
+		GroupOperator: jsii.String("all"),
+		Enabled:       jsii.Bool(true),
+		Reason:        jsii.String("Login rate limit"),
+		Signal:        jsii.String("site.login-attempt"),
+		Conditions: &[]*siterule.SiteRuleConditions{
+			{
+				Type:     jsii.String("single"),
+				Field:    jsii.String("path"),
+				Operator: jsii.String("equals"),
+				Value:    jsii.String("/login"),
+			},
+		},
+		Actions: &[]*siterule.SiteRuleActions{
+			{
+				Type:   jsii.String("logRequest"),
+				Signal: jsii.String("site.login-attempt"),
+			},
+		},
+		RateLimit: &siterule.SiteRuleRateLimit{
+			Threshold: jsii.Number(10),
+			Interval:  jsii.Number(1),
+			Duration:  jsii.Number(600),
+			ClientIdentifiers: &[]*siterule.SiteRuleRateLimitClientIdentifiers{
+				{
+					Type: jsii.String("ip"),
+				},
+			},
+		},
+	}).ImportFrom(jsii.String("www:61b2c3d4e5f60718293a4b5d"), nil)
+
+	siterule.NewSiteRule(stack, jsii.String("GBbCcDdEeFfGAHBICJDaEbFe"), &siterule.SiteRuleConfig{
+		SiteShortName: jsii.String("www"),
+		Type:          jsii.String("templatedSignal"),
+		GroupOperator: jsii.String("all"),
+		Enabled:       jsii.Bool(true),
+		Reason:        jsii.String(""),
+		Signal:        jsii.String("LOGINATTEMPT"),
+		Conditions: &[]*siterule.SiteRuleConditions{
+			{
+				Type:     jsii.String("single"),
+				Field:    jsii.String("path"),
+				Operator: jsii.String("equals"),
+				Value:    jsii.String("/login"),
+			},
+		},
+		Actions: &[]*siterule.SiteRuleActions{
+			{
+				Type:   jsii.String("addSignal"),
+				Signal: jsii.String("LOGINATTEMPT"),
+			},
+		},
+	}).ImportFrom(jsii.String("www:61b2c3d4e5f60718293a4b5e"), nil)
+
+	siterule.NewSiteRule(stack, jsii.String("GBbCcDdEeFfGAHBICJDaEbGA"), &siterule.SiteRuleConfig{
+		SiteShortName: jsii.String("www"),
+		Type:          jsii.String("request"),
+		GroupOperator: jsii.String("all"),
+		Enabled:       jsii.Bool(true),
+		Reason:        jsii.String("Tag traffic from blocked IPs"),
+		Conditions: &[]*siterule.SiteRuleConditions{
+			{
+				Type:     jsii.String("single"),
+				Field:    jsii.String("ip"),
+				Operator: jsii.String("inList"),
+				Value:    jsii.String("corp.blocked-ips"),
+			},
+		},
+		Actions: &[]*siterule.SiteRuleActions{
+			{
+				Type:   jsii.String("addSignal"),
+				Signal: jsii.String("corp.bad-bot"),
+			},
+		},
+	}).ImportFrom(jsii.String("www:61b2c3d4e5f60718293a4b60"), nil)
+
+	sitetemplatedrule.NewSiteTemplatedRule(stack, jsii.String("wwwLOGINATTEMPT"), &sitetemplatedrule.SiteTemplatedRuleConfig{
+		SiteShortName: jsii.String("www"),
+		Name:          jsii.String("LOGINATTEMPT"),
+		Detections: &[]*sitetemplatedrule.SiteTemplatedRuleDetections{
+			{
+				Enabled: jsii.Bool(true),
+				Fields: &[]*sitetemplatedrule.SiteTemplatedRuleDetectionsFields{
+					{
+						Name:  jsii.String("path"),
+						Value: jsii.String("/login"),
+					},
+				},
+			},
+		},
+		Alerts: &[]*sitetemplatedrule.SiteTemplatedRuleAlerts{
+			{
+				LongName:          jsii.String("LOGINATTEMPT alert"),
+				Interval:          jsii.Number(1),
+				Threshold:         jsii.Number(10),
+				SkipNotifications: jsii.Bool(false),
+				Enabled:           jsii.Bool(true),
+				Action:            jsii.String("info"),
+			},
+		},
+	}).ImportFrom(jsii.String("www:LOGINATTEMPT"), nil)
+
+	sitesignaltag.NewSiteSignalTag(stack, jsii.String("wwwsitedotlogin-attempt"), &sitesignaltag.SiteSignalTagConfig{
+		SiteShortName: jsii.String("www"),
+		Name:          jsii.String("login-attempt"),
+		Description:   jsii.String("Login attempts"),
+	}).ImportFrom(jsii.String("www:site.login-attempt"), nil)
+
+	sitelist.NewSiteList(stack, jsii.String("wwwsitedotoffice-ips"), &sitelist.SiteListConfig{
+		SiteShortName: jsii.String("www"),
+		Name:          jsii.String("Office IPs"),
+		Type:          jsii.String("ip"),
+		Description:   jsii.String("Office egress addresses"),
+		Entries:       jsii.Strings("192.0.2.10", "192.0.2.11"),
+	}).ImportFrom(jsii.String("www:site.office-ips"), nil)
+
+	siteintegration.NewSiteIntegration(stack, jsii.String("GCcDdEeFfGAHBICJDaEbFcGd"), &siteintegration.SiteIntegrationConfig{
+		SiteShortName: jsii.String("www"),
+		Type:          jsii.String("slack"),
+		Url:           cdktf.NewTerraformVariable(stack, jsii.String("site_integration_GCcDdEeFfGAHBICJDaEbFcGd_url"), &cdktf.TerraformVariableConfig{Type: jsii.String("string"), Sensitive: jsii.Bool(true)}).StringValue(),
+		Events:        jsii.Strings("listCreated", "flag"),
+	}).ImportFrom(jsii.String("www:62c3d4e5f60718293a4b5c6d"), nil)
+
+	siteheaderlink.NewSiteHeaderLink(stack, jsii.String("wwwGDdEeFfGAHBICJDaEbFcGdHe"), &siteheaderlink.SiteHeaderLinkConfig{
+		SiteShortName: jsii.String("www"),
+		Type:          jsii.String("request"),
+		Name:          jsii.String("X-Request-Id"),
+		LinkName:      jsii.String("Trace"),
+		Link:          jsii.String("https://tracing.example.com/trace/{{value}}"),
+	}).ImportFrom(jsii.String("www:63d4e5f60718293a4b5c6d7e"), nil)
+
+	siteagentalert.NewSiteAgentAlert(stack, jsii.String("GEeFfGAHBICJDaEbFcGdHeJA"), &siteagentalert.SiteAgentAlertConfig{
+		SiteShortName:     jsii.String("www"),
+		TagName:           jsii.String("requests_total"),
+		LongName:          jsii.String("Agent request spike"),
+		Interval:          jsii.Number(5),
+		Threshold:         jsii.Number(1000),
+		Enabled:           jsii.Bool(true),
+		Action:            jsii.String("siteMetricInfo"),
+		SkipNotifications: jsii.Bool(true),
+	}).ImportFrom(jsii.String("www:64e5f60718293a4b5c6d7e90"), nil)
+
+	sitealert.NewSiteAlert(stack, jsii.String("GEeFfGAHBICJDaEbFcGdHeIf"), &sitealert.SiteAlertConfig{
+		SiteShortName:     jsii.String("www"),
+		TagName:           jsii.String("site.login-attempt"),
+		LongName:          jsii.String("Too many logins"),
+		Interval:          jsii.Number(10),
+		Threshold:         jsii.Number(50),
+		Enabled:           jsii.Bool(true),
+		Action:            jsii.String("info"),
+		SkipNotifications: jsii.Bool(false),
+	}).ImportFrom(jsii.String("www:64e5f60718293a4b5c6d7e8f"), nil)
+
+	sitealert.NewSiteAlert(stack, jsii.String("GEeFfGAHBICJDaEbFcGdHeJB"), &sitealert.SiteAlertConfig{
+		SiteShortName:     jsii.String("www"),
+		TagName:           jsii.String("SQLI"),
+		LongName:          jsii.String("SQLi attack"),
+		Interval:          jsii.Number(1),
+		Threshold:         jsii.Number(10),
+		Enabled:           jsii.Bool(true),
+		Action:            jsii.String("flagged"),
+		SkipNotifications: jsii.Bool(false),
+	}).ImportFrom(jsii.String("www:64e5f60718293a4b5c6d7e91"), nil)
+
+	siterule.NewSiteRule(stack, jsii.String("GGaHBICJDaEbFcGdHeIfJABC"), &siterule.SiteRuleConfig{
+		SiteShortName: jsii.String("api2"),
+		Type:          jsii.String("request"),
+		GroupOperator: jsii.String("all"),
+		Enabled:       jsii.Bool(true),
+		Reason:        jsii.String("Allow partner"),
+		Conditions: &[]*siterule.SiteRuleConditions{
+			{
+				Type:     jsii.String("single"),
+				Field:    jsii.String("requestHeader"),
+				Operator: jsii.String("equals"),
+				Value:    jsii.String("X-Partner"),
+			},
+		},
+		Actions: &[]*siterule.SiteRuleActions{
+			{
+				Type: jsii.String("allow"),
+			},
+		},
+	}).ImportFrom(jsii.String("api2:66a718293a4b5c6d7e8f9012"), nil)
+
+	return stack
+}
+
+func main() {
+	app := cdktf.NewApp(nil)
+	NewNgwafStack(app, "testcorp")
+	app.Synth()
+}
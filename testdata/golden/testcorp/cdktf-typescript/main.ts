@@ -0,0 +1,279 @@
+// Generated by ngwaf-terraformify. Run `cdktf get` to generate the provider bindings.
+import { Construct } from "constructs";
+import { App, TerraformStack, TerraformVariable } from "cdktf";
+import { SigsciProvider } from "./.gen/providers/sigsci/provider";
+import { CorpList } from "./.gen/providers/sigsci/corp-list";
+import { CorpRule } from "./.gen/providers/sigsci/corp-rule";
+import { CorpSignalTag } from "./.gen/providers/sigsci/corp-signal-tag";
+import { Site } from "./.gen/providers/sigsci/site";
+import { SiteAgentAlert } from "./.gen/providers/sigsci/site-agent-alert";
+import { SiteAlert } from "./.gen/providers/sigsci/site-alert";
+import { SiteHeaderLink } from "./.gen/providers/sigsci/site-header-link";
+import { SiteIntegration } from "./.gen/providers/sigsci/site-integration";
+import { SiteList } from "./.gen/providers/sigsci/site-list";
+import { SiteRule } from "./.gen/providers/sigsci/site-rule";
+import { SiteSignalTag } from "./.gen/providers/sigsci/site-signal-tag";
+import { SiteTemplatedRule } from "./.gen/providers/sigsci/site-templated-rule";
+
+class NgwafStack extends TerraformStack {
+  constructor(scope: Construct, id: string) {
+    super(scope, id);
+
+    const ngwafEmail = new TerraformVariable(this, "NGWAF_EMAIL", { type: "string" });
+    const ngwafToken = new TerraformVariable(this, "NGWAF_TOKEN", { type: "string", sensitive: true });
+
+    new SigsciProvider(this, "sigsci", {
+      corp: "testcorp",
+      email: ngwafEmail.stringValue,
+      authToken: ngwafToken.stringValue,
+    });
+
+    new CorpRule(this, "GAaBbCcDdEeFfGAHBICJDaEb", {
+      siteShortNames: [],
+      type: "request",
+      corpScope: "global",
+      groupOperator: "all",
+      enabled: true,
+      reason: "Block known bad IPs",
+      conditions: [{
+        type: "single",
+        field: "ip",
+        operator: "inList",
+        value: "corp.blocked-ips",
+      }],
+      actions: [{
+        type: "block",
+      }],
+    }).importFrom("60a1b2c3d4e5f60718293a4b");
+
+    new CorpList(this, "corpdotblocked-ips", {
+      name: "Blocked IPs",
+      type: "ip",
+      description: "Known bad actors",
+      entries: ["203.0.113.7", "198.51.100.0/24", "192.0.2.1"],
+    }).importFrom("corp.blocked-ips");
+
+    new CorpSignalTag(this, "corpdotbad-bot", {
+      shortName: "bad-bot",
+      description: "Known bad bots",
+    }).importFrom("corp.bad-bot");
+
+    new Site(this, "www", {
+      shortName: "www",
+      displayName: "Main website",
+      agentLevel: "block",
+      blockHttpCode: 406,
+      blockDurationSeconds: 86400,
+    }).importFrom("www");
+
+    new Site(this, "apiC", {
+      shortName: "api2",
+      displayName: "API v2",
+      agentLevel: "log",
+      agentAnonMode: "EU",
+      blockHttpCode: 406,
+      blockDurationSeconds: 86400,
+      clientIpRules: [{
+        header: "X-Forwarded-For",
+      }],
+    }).importFrom("api2");
+
+    new SiteRule(this, "GBbCcDdEeFfGAHBICJDaEbFc", {
+      siteShortName: "www",
+      type: "request",
+      groupOperator: "any",
+      enabled: true,
+      reason: "Block admin from outside the office",
+      conditions: [{
+        type: "single",
+        field: "path",
+        operator: "prefix",
+        value: "/admin",
+      }, {
+        type: "group",
+        groupOperator: "all",
+        conditions: [{
+          type: "single",
+          field: "ip",
+          operator: "notInList",
+          value: "site.office-ips",
+        }, {
+          type: "single",
+          field: "method",
+          operator: "equals",
+          value: "POST",
+        }],
+      }],
+      actions: [{
+        type: "block",
+      }],
+    }).importFrom("www:61b2c3d4e5f60718293a4b5c");
+
+    new SiteRule(this, "GBbCcDdEeFfGAHBICJDaEbFd", {
+      siteShortName: "www",
+      type: "rateLimit",
+      groupOperator: "all",
+      enabled: true,
+      reason: "Login rate limit",
+      signal: "site.login-attempt",
+      conditions: [{
+        type: "single",
+        field: "path",
+        operator: "equals",
+        value: "/login",
+      }],
+      actions: [{
+        type: "logRequest",
+        signal: "site.login-attempt",
+      }],
+      rateLimit: {
+        threshold: 10,
+        interval: 1,
+        duration: 600,
+        clientIdentifiers: [{
+          type: "ip",
+        }],
+      },
+    }).importFrom("www:61b2c3d4e5f60718293a4b5d");
+
+    new SiteRule(this, "GBbCcDdEeFfGAHBICJDaEbFe", {
+      siteShortName: "www",
+      type: "templatedSignal",
+      groupOperator: "all",
+      enabled: true,
+      reason: "",
+      signal: "LOGINATTEMPT",
+      conditions: [{
+        type: "single",
+        field: "path",
+        operator: "equals",
+        value: "/login",
+      }],
+      actions: [{
+        type: "addSignal",
+        signal: "LOGINATTEMPT",
+      }],
+    }).importFrom("www:61b2c3d4e5f60718293a4b5e");
+
+    new SiteRule(this, "GBbCcDdEeFfGAHBICJDaEbGA", {
+      siteShortName: "www",
+      type: "request",
+      groupOperator: "all",
+      enabled: true,
+      reason: "Tag traffic from blocked IPs",
+      conditions: [{
+        type: "single",
+        field: "ip",
+        operator: "inList",
+        value: "corp.blocked-ips",
+      }],
+      actions: [{
+        type: "addSignal",
+        signal: "corp.bad-bot",
+      }],
+    }).importFrom("www:61b2c3d4e5f60718293a4b60");
+
+    new SiteTemplatedRule(this, "wwwLOGINATTEMPT", {
+      siteShortName: "www",
+      name: "LOGINATTEMPT",
+      detections: [{
+        enabled: true,
+        fields: [{
+          name: "path",
+          value: "/login",
+        }],
+      }],
+      alerts: [{
+        longName: "LOGINATTEMPT alert",
+        interval: 1,
+        threshold: 10,
+        skipNotifications: false,
+        enabled: true,
+        action: "info",
+      }],
+    }).importFrom("www:LOGINATTEMPT");
+
+    new SiteSignalTag(this, "wwwsitedotlogin-attempt", {
+      siteShortName: "www",
+      name: "login-attempt",
+      description: "Login attempts",
+    }).importFrom("www:site.login-attempt");
+
+    new SiteList(this, "wwwsitedotoffice-ips", {
+      siteShortName: "www",
+      name: "Office IPs",
+      type: "ip",
+      description: "Office egress addresses",
+      entries: ["192.0.2.10", "192.0.2.11"],
+    }).importFrom("www:site.office-ips");
+
+    new SiteIntegration(this, "GCcDdEeFfGAHBICJDaEbFcGd", {
+      siteShortName: "www",
+      type: "slack",
+      url: new TerraformVariable(this, "site_integration_GCcDdEeFfGAHBICJDaEbFcGd_url", { type: "string", sensitive: true }).stringValue,
+      events: ["listCreated", "flag"],
+    }).importFrom("www:62c3d4e5f60718293a4b5c6d");
+
+    new SiteHeaderLink(this, "wwwGDdEeFfGAHBICJDaEbFcGdHe", {
+      siteShortName: "www",
+      type: "request",
+      name: "X-Request-Id",
+      linkName: "Trace",
+      link: "https://tracing.example.com/trace/{{value}}",
+    }).importFrom("www:63d4e5f60718293a4b5c6d7e");
+
+    new SiteAgentAlert(this, "GEeFfGAHBICJDaEbFcGdHeJA", {
+      siteShortName: "www",
+      tagName: "requests_total",
+      longName: "Agent request spike",
+      interval: 5,
+      threshold: 1000,
+      enabled: true,
+      action: "siteMetricInfo",
+      skipNotifications: true,
+    }).importFrom("www:64e5f60718293a4b5c6d7e90");
+
+    new SiteAlert(this, "GEeFfGAHBICJDaEbFcGdHeIf", {
+      siteShortName: "www",
+      tagName: "site.login-attempt",
+      longName: "Too many logins",
+      interval: 10,
+      threshold: 50,
+      enabled: true,
+      action: "info",
+      skipNotifications: false,
+    }).importFrom("www:64e5f60718293a4b5c6d7e8f");
+
+    new SiteAlert(this, "GEeFfGAHBICJDaEbFcGdHeJB", {
+      siteShortName: "www",
+      tagName: "SQLI",
+      longName: "SQLi attack",
+      interval: 1,
+      threshold: 10,
+      enabled: true,
+      action: "flagged",
+      skipNotifications: false,
+    }).importFrom("www:64e5f60718293a4b5c6d7e91");
+
+    new SiteRule(this, "GGaHBICJDaEbFcGdHeIfJABC", {
+      siteShortName: "api2",
+      type: "request",
+      groupOperator: "all",
+      enabled: true,
+      reason: "Allow partner",
+      conditions: [{
+        type: "single",
+        field: "requestHeader",
+        operator: "equals",
+        value: "X-Partner",
+      }],
+      actions: [{
+        type: "allow",
+      }],
+    }).importFrom("api2:66a718293a4b5c6d7e8f9012");
+  }
+}
+
+const app = new App();
+new NgwafStack(app, "testcorp");
+app.synth();
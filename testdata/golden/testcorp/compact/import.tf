@@ -0,0 +1,562 @@
+locals {
+  sigsci_corp_rule_ids = {
+    GAaBbCcDdEeFfGAHBICJDaEb = "60a1b2c3d4e5f60718293a4b"
+  }
+  sigsci_corp_rule = {
+    GAaBbCcDdEeFfGAHBICJDaEb = {
+      actions = [{
+        type = "block"
+      }]
+      conditions = [{
+        field    = "ip"
+        operator = "inList"
+        type     = "single"
+        value    = "corp.blocked-ips"
+      }]
+      corp_scope       = "global"
+      enabled          = true
+      group_operator   = "all"
+      reason           = "Block known bad IPs"
+      site_short_names = []
+      type             = "request"
+    }
+  }
+  sigsci_corp_list_ids = {
+    corpdotblocked-ips = "corp.blocked-ips"
+  }
+  sigsci_corp_list = {
+    corpdotblocked-ips = {
+      description = "Known bad actors"
+      entries     = ["203.0.113.7", "198.51.100.0/24", "192.0.2.1"]
+      name        = "Blocked IPs"
+      type        = "ip"
+    }
+  }
+  sigsci_corp_signal_tag_ids = {
+    corpdotbad-bot = "corp.bad-bot"
+  }
+  sigsci_corp_signal_tag = {
+    corpdotbad-bot = {
+      description = "Known bad bots"
+      short_name  = "bad-bot"
+    }
+  }
+  sigsci_site_ids = {
+    apiC = "api2"
+    www  = "www"
+  }
+  sigsci_site = {
+    apiC = {
+      agent_anon_mode        = "EU"
+      agent_level            = "log"
+      block_duration_seconds = 86400
+      block_http_code        = 406
+      client_ip_rules = [{
+        header = "X-Forwarded-For"
+      }]
+      display_name = "API v2"
+      short_name   = "api2"
+    }
+    www = {
+      agent_level            = "block"
+      block_duration_seconds = 86400
+      block_http_code        = 406
+      display_name           = "Main website"
+      short_name             = "www"
+    }
+  }
+  sigsci_site_rule_ids = {
+    GBbCcDdEeFfGAHBICJDaEbFc = "www:61b2c3d4e5f60718293a4b5c"
+    GBbCcDdEeFfGAHBICJDaEbFd = "www:61b2c3d4e5f60718293a4b5d"
+    GBbCcDdEeFfGAHBICJDaEbFe = "www:61b2c3d4e5f60718293a4b5e"
+    GBbCcDdEeFfGAHBICJDaEbGA = "www:61b2c3d4e5f60718293a4b60"
+    GGaHBICJDaEbFcGdHeIfJABC = "api2:66a718293a4b5c6d7e8f9012"
+  }
+  sigsci_site_rule = {
+    GBbCcDdEeFfGAHBICJDaEbFc = {
+      actions = [{
+        type = "block"
+      }]
+      conditions = [{
+        field    = "path"
+        operator = "prefix"
+        type     = "single"
+        value    = "/admin"
+        }, {
+        conditions = [{
+          field    = "ip"
+          operator = "notInList"
+          type     = "single"
+          value    = "site.office-ips"
+          }, {
+          field    = "method"
+          operator = "equals"
+          type     = "single"
+          value    = "POST"
+        }]
+        group_operator = "all"
+        type           = "group"
+      }]
+      enabled         = true
+      group_operator  = "any"
+      reason          = "Block admin from outside the office"
+      site_short_name = "www"
+      type            = "request"
+    }
+    GBbCcDdEeFfGAHBICJDaEbFd = {
+      actions = [{
+        signal = "site.login-attempt"
+        type   = "logRequest"
+      }]
+      conditions = [{
+        field    = "path"
+        operator = "equals"
+        type     = "single"
+        value    = "/login"
+      }]
+      enabled        = true
+      group_operator = "all"
+      rate_limit = [{
+        client_identifiers = [{
+          type = "ip"
+        }]
+        duration  = 600
+        interval  = 1
+        threshold = 10
+      }]
+      reason          = "Login rate limit"
+      signal          = "site.login-attempt"
+      site_short_name = "www"
+      type            = "rateLimit"
+    }
+    GBbCcDdEeFfGAHBICJDaEbFe = {
+      actions = [{
+        signal = "LOGINATTEMPT"
+        type   = "addSignal"
+      }]
+      conditions = [{
+        field    = "path"
+        operator = "equals"
+        type     = "single"
+        value    = "/login"
+      }]
+      enabled         = true
+      group_operator  = "all"
+      reason          = ""
+      signal          = "LOGINATTEMPT"
+      site_short_name = "www"
+      type            = "templatedSignal"
+    }
+    GBbCcDdEeFfGAHBICJDaEbGA = {
+      actions = [{
+        signal = "corp.bad-bot"
+        type   = "addSignal"
+      }]
+      conditions = [{
+        field    = "ip"
+        operator = "inList"
+        type     = "single"
+        value    = "corp.blocked-ips"
+      }]
+      enabled         = true
+      group_operator  = "all"
+      reason          = "Tag traffic from blocked IPs"
+      site_short_name = "www"
+      type            = "request"
+    }
+    GGaHBICJDaEbFcGdHeIfJABC = {
+      actions = [{
+        type = "allow"
+      }]
+      conditions = [{
+        field    = "requestHeader"
+        operator = "equals"
+        type     = "single"
+        value    = "X-Partner"
+      }]
+      enabled         = true
+      group_operator  = "all"
+      reason          = "Allow partner"
+      site_short_name = "api2"
+      type            = "request"
+    }
+  }
+  sigsci_site_templated_rule_ids = {
+    wwwLOGINATTEMPT = "www:LOGINATTEMPT"
+  }
+  sigsci_site_templated_rule = {
+    wwwLOGINATTEMPT = {
+      alerts = [{
+        action             = "info"
+        enabled            = true
+        interval           = 1
+        long_name          = "LOGINATTEMPT alert"
+        skip_notifications = false
+        threshold          = 10
+      }]
+      detections = [{
+        enabled = true
+        fields = [{
+          name  = "path"
+          value = "/login"
+        }]
+      }]
+      name            = "LOGINATTEMPT"
+      site_short_name = "www"
+    }
+  }
+  sigsci_site_signal_tag_ids = {
+    wwwsitedotlogin-attempt = "www:site.login-attempt"
+  }
+  sigsci_site_signal_tag = {
+    wwwsitedotlogin-attempt = {
+      description     = "Login attempts"
+      name            = "login-attempt"
+      site_short_name = "www"
+    }
+  }
+  sigsci_site_list_ids = {
+    wwwsitedotoffice-ips = "www:site.office-ips"
+  }
+  sigsci_site_list = {
+    wwwsitedotoffice-ips = {
+      description     = "Office egress addresses"
+      entries         = ["192.0.2.10", "192.0.2.11"]
+      name            = "Office IPs"
+      site_short_name = "www"
+      type            = "ip"
+    }
+  }
+  sigsci_site_integration_ids = {
+    GCcDdEeFfGAHBICJDaEbFcGd = "www:62c3d4e5f60718293a4b5c6d"
+  }
+  sigsci_site_integration = {
+    GCcDdEeFfGAHBICJDaEbFcGd = {
+      events          = ["listCreated", "flag"]
+      site_short_name = "www"
+      type            = "slack"
+      url             = var.site_integration_GCcDdEeFfGAHBICJDaEbFcGd_url
+    }
+  }
+  sigsci_site_header_link_ids = {
+    wwwGDdEeFfGAHBICJDaEbFcGdHe = "www:63d4e5f60718293a4b5c6d7e"
+  }
+  sigsci_site_header_link = {
+    wwwGDdEeFfGAHBICJDaEbFcGdHe = {
+      link            = "https://tracing.example.com/trace/{{value}}"
+      link_name       = "Trace"
+      name            = "X-Request-Id"
+      site_short_name = "www"
+      type            = "request"
+    }
+  }
+  sigsci_site_agent_alert_ids = {
+    GEeFfGAHBICJDaEbFcGdHeJA = "www:64e5f60718293a4b5c6d7e90"
+  }
+  sigsci_site_agent_alert = {
+    GEeFfGAHBICJDaEbFcGdHeJA = {
+      action             = "siteMetricInfo"
+      enabled            = true
+      interval           = 5
+      long_name          = "Agent request spike"
+      site_short_name    = "www"
+      skip_notifications = true
+      tag_name           = "requests_total"
+      threshold          = 1000
+    }
+  }
+  sigsci_site_alert_ids = {
+    GEeFfGAHBICJDaEbFcGdHeIf = "www:64e5f60718293a4b5c6d7e8f"
+    GEeFfGAHBICJDaEbFcGdHeJB = "www:64e5f60718293a4b5c6d7e91"
+  }
+  sigsci_site_alert = {
+    GEeFfGAHBICJDaEbFcGdHeIf = {
+      action             = "info"
+      enabled            = true
+      interval           = 10
+      long_name          = "Too many logins"
+      site_short_name    = "www"
+      skip_notifications = false
+      tag_name           = "site.login-attempt"
+      threshold          = 50
+    }
+    GEeFfGAHBICJDaEbFcGdHeJB = {
+      action             = "flagged"
+      enabled            = true
+      interval           = 1
+      long_name          = "SQLi attack"
+      site_short_name    = "www"
+      skip_notifications = false
+      tag_name           = "SQLI"
+      threshold          = 10
+    }
+  }
+}
+
+import {
+  for_each = local.sigsci_corp_rule_ids
+  to       = sigsci_corp_rule.this[each.key]
+  id       = each.value
+}
+
+resource "sigsci_corp_rule" "this" {
+  for_each         = local.sigsci_corp_rule
+  site_short_names = each.value.site_short_names
+  type             = each.value.type
+  corp_scope       = each.value.corp_scope
+  group_operator   = each.value.group_operator
+  enabled          = each.value.enabled
+  reason           = each.value.reason
+  dynamic "conditions" {
+    for_each = try(each.value.conditions, [])
+    content {
+      type     = conditions.value.type
+      field    = conditions.value.field
+      operator = conditions.value.operator
+      value    = conditions.value.value
+    }
+  }
+  dynamic "actions" {
+    for_each = try(each.value.actions, [])
+    content {
+      type = actions.value.type
+    }
+  }
+}
+
+import {
+  for_each = local.sigsci_corp_list_ids
+  to       = sigsci_corp_list.this[each.key]
+  id       = each.value
+}
+
+resource "sigsci_corp_list" "this" {
+  for_each    = local.sigsci_corp_list
+  name        = each.value.name
+  type        = each.value.type
+  description = each.value.description
+  entries     = each.value.entries
+}
+
+import {
+  for_each = local.sigsci_corp_signal_tag_ids
+  to       = sigsci_corp_signal_tag.this[each.key]
+  id       = each.value
+}
+
+resource "sigsci_corp_signal_tag" "this" {
+  for_each    = local.sigsci_corp_signal_tag
+  short_name  = each.value.short_name
+  description = each.value.description
+}
+
+import {
+  for_each = local.sigsci_site_ids
+  to       = sigsci_site.this[each.key]
+  id       = each.value
+}
+
+resource "sigsci_site" "this" {
+  for_each               = local.sigsci_site
+  short_name             = each.value.short_name
+  display_name           = each.value.display_name
+  agent_level            = each.value.agent_level
+  block_http_code        = each.value.block_http_code
+  block_duration_seconds = each.value.block_duration_seconds
+  agent_anon_mode        = try(each.value.agent_anon_mode, null)
+  dynamic "client_ip_rules" {
+    for_each = try(each.value.client_ip_rules, [])
+    content {
+      header = client_ip_rules.value.header
+    }
+  }
+}
+
+import {
+  for_each = local.sigsci_site_rule_ids
+  to       = sigsci_site_rule.this[each.key]
+  id       = each.value
+}
+
+resource "sigsci_site_rule" "this" {
+  for_each        = local.sigsci_site_rule
+  site_short_name = each.value.site_short_name
+  type            = each.value.type
+  group_operator  = each.value.group_operator
+  enabled         = each.value.enabled
+  reason          = each.value.reason
+  signal          = try(each.value.signal, null)
+  dynamic "conditions" {
+    for_each = try(each.value.conditions, [])
+    content {
+      type           = conditions.value.type
+      field          = try(conditions.value.field, null)
+      operator       = try(conditions.value.operator, null)
+      value          = try(conditions.value.value, null)
+      group_operator = try(conditions.value.group_operator, null)
+      dynamic "conditions" {
+        for_each = try(conditions.value.conditions, [])
+        iterator = conditions_conditions
+        content {
+          type     = conditions_conditions.value.type
+          field    = conditions_conditions.value.field
+          operator = conditions_conditions.value.operator
+          value    = conditions_conditions.value.value
+        }
+      }
+    }
+  }
+  dynamic "actions" {
+    for_each = try(each.value.actions, [])
+    content {
+      type   = actions.value.type
+      signal = try(actions.value.signal, null)
+    }
+  }
+  dynamic "rate_limit" {
+    for_each = try(each.value.rate_limit, [])
+    content {
+      threshold = rate_limit.value.threshold
+      interval  = rate_limit.value.interval
+      duration  = rate_limit.value.duration
+      dynamic "client_identifiers" {
+        for_each = try(rate_limit.value.client_identifiers, [])
+        iterator = rate_limit_client_identifiers
+        content {
+          type = rate_limit_client_identifiers.value.type
+        }
+      }
+    }
+  }
+}
+
+import {
+  for_each = local.sigsci_site_templated_rule_ids
+  to       = sigsci_site_templated_rule.this[each.key]
+  id       = each.value
+}
+
+resource "sigsci_site_templated_rule" "this" {
+  for_each        = local.sigsci_site_templated_rule
+  site_short_name = each.value.site_short_name
+  name            = each.value.name
+  dynamic "detections" {
+    for_each = try(each.value.detections, [])
+    content {
+      enabled = detections.value.enabled
+      dynamic "fields" {
+        for_each = try(detections.value.fields, [])
+        iterator = detections_fields
+        content {
+          name  = detections_fields.value.name
+          value = detections_fields.value.value
+        }
+      }
+    }
+  }
+  dynamic "alerts" {
+    for_each = try(each.value.alerts, [])
+    content {
+      long_name          = alerts.value.long_name
+      interval           = alerts.value.interval
+      threshold          = alerts.value.threshold
+      skip_notifications = alerts.value.skip_notifications
+      enabled            = alerts.value.enabled
+      action             = alerts.value.action
+    }
+  }
+}
+
+import {
+  for_each = local.sigsci_site_signal_tag_ids
+  to       = sigsci_site_signal_tag.this[each.key]
+  id       = each.value
+}
+
+resource "sigsci_site_signal_tag" "this" {
+  for_each        = local.sigsci_site_signal_tag
+  site_short_name = each.value.site_short_name
+  name            = each.value.name
+  description     = each.value.description
+}
+
+import {
+  for_each = local.sigsci_site_list_ids
+  to       = sigsci_site_list.this[each.key]
+  id       = each.value
+}
+
+resource "sigsci_site_list" "this" {
+  for_each        = local.sigsci_site_list
+  site_short_name = each.value.site_short_name
+  name            = each.value.name
+  type            = each.value.type
+  description     = each.value.description
+  entries         = each.value.entries
+}
+
+import {
+  for_each = local.sigsci_site_integration_ids
+  to       = sigsci_site_integration.this[each.key]
+  id       = each.value
+}
+
+resource "sigsci_site_integration" "this" {
+  for_each        = local.sigsci_site_integration
+  site_short_name = each.value.site_short_name
+  type            = each.value.type
+  url             = each.value.url
+  events          = each.value.events
+}
+
+import {
+  for_each = local.sigsci_site_header_link_ids
+  to       = sigsci_site_header_link.this[each.key]
+  id       = each.value
+}
+
+resource "sigsci_site_header_link" "this" {
+  for_each        = local.sigsci_site_header_link
+  site_short_name = each.value.site_short_name
+  type            = each.value.type
+  name            = each.value.name
+  link_name       = each.value.link_name
+  link            = each.value.link
+}
+
+import {
+  for_each = local.sigsci_site_agent_alert_ids
+  to       = sigsci_site_agent_alert.this[each.key]
+  id       = each.value
+}
+
+resource "sigsci_site_agent_alert" "this" {
+  for_each           = local.sigsci_site_agent_alert
+  site_short_name    = each.value.site_short_name
+  tag_name           = each.value.tag_name
+  long_name          = each.value.long_name
+  interval           = each.value.interval
+  threshold          = each.value.threshold
+  enabled            = each.value.enabled
+  action             = each.value.action
+  skip_notifications = each.value.skip_notifications
+}
+
+import {
+  for_each = local.sigsci_site_alert_ids
+  to       = sigsci_site_alert.this[each.key]
+  id       = each.value
+}
+
+resource "sigsci_site_alert" "this" {
+  for_each           = local.sigsci_site_alert
+  site_short_name    = each.value.site_short_name
+  tag_name           = each.value.tag_name
+  long_name          = each.value.long_name
+  interval           = each.value.interval
+  threshold          = each.value.threshold
+  enabled            = each.value.enabled
+  action             = each.value.action
+  skip_notifications = each.value.skip_notifications
+}
+
@@ -0,0 +1,23 @@
+#!/bin/sh
+# Imports existing NGWAF objects into the Terraform state.
+# The resource blocks must already exist in the configuration.
+set -e
+
+terraform import 'sigsci_corp_rule.GAaBbCcDdEeFfGAHBICJDaEb' '60a1b2c3d4e5f60718293a4b'
+terraform import 'sigsci_corp_list.corpdotblocked-ips' 'corp.blocked-ips'
+terraform import 'sigsci_corp_signal_tag.corpdotbad-bot' 'corp.bad-bot'
+terraform import 'sigsci_site.www' 'www'
+terraform import 'sigsci_site.apiC' 'api2'
+terraform import 'sigsci_site_rule.GBbCcDdEeFfGAHBICJDaEbFc' 'www:61b2c3d4e5f60718293a4b5c'
+terraform import 'sigsci_site_rule.GBbCcDdEeFfGAHBICJDaEbFd' 'www:61b2c3d4e5f60718293a4b5d'
+terraform import 'sigsci_site_rule.GBbCcDdEeFfGAHBICJDaEbFe' 'www:61b2c3d4e5f60718293a4b5e'
+terraform import 'sigsci_site_rule.GBbCcDdEeFfGAHBICJDaEbGA' 'www:61b2c3d4e5f60718293a4b60'
+terraform import 'sigsci_site_templated_rule.wwwLOGINATTEMPT' 'www:LOGINATTEMPT'
+terraform import 'sigsci_site_signal_tag.wwwsitedotlogin-attempt' 'www:site.login-attempt'
+terraform import 'sigsci_site_list.wwwsitedotoffice-ips' 'www:site.office-ips'
+terraform import 'sigsci_site_integration.GCcDdEeFfGAHBICJDaEbFcGd' 'www:62c3d4e5f60718293a4b5c6d'
+terraform import 'sigsci_site_header_link.wwwGDdEeFfGAHBICJDaEbFcGdHe' 'www:63d4e5f60718293a4b5c6d7e'
+terraform import 'sigsci_site_agent_alert.GEeFfGAHBICJDaEbFcGdHeJA' 'www:64e5f60718293a4b5c6d7e90'
+terraform import 'sigsci_site_alert.GEeFfGAHBICJDaEbFcGdHeIf' 'www:64e5f60718293a4b5c6d7e8f'
+terraform import 'sigsci_site_alert.GEeFfGAHBICJDaEbFcGdHeJB' 'www:64e5f60718293a4b5c6d7e91'
+terraform import 'sigsci_site_rule.GGaHBICJDaEbFcGdHeIfJABC' 'api2:66a718293a4b5c6d7e8f9012'
@@ -0,0 +1,72 @@
+import {
+  id = "60a1b2c3d4e5f60718293a4b"
+  to = sigsci_corp_rule.GAaBbCcDdEeFfGAHBICJDaEb
+}
+import {
+  id = "corp.blocked-ips"
+  to = sigsci_corp_list.corpdotblocked-ips
+}
+import {
+  id = "corp.bad-bot"
+  to = sigsci_corp_signal_tag.corpdotbad-bot
+}
+import {
+  id = "www"
+  to = sigsci_site.www
+}
+import {
+  id = "api2"
+  to = sigsci_site.apiC
+}
+import {
+  id = "www:61b2c3d4e5f60718293a4b5c"
+  to = sigsci_site_rule.GBbCcDdEeFfGAHBICJDaEbFc
+}
+import {
+  id = "www:61b2c3d4e5f60718293a4b5d"
+  to = sigsci_site_rule.GBbCcDdEeFfGAHBICJDaEbFd
+}
+import {
+  id = "www:61b2c3d4e5f60718293a4b5e"
+  to = sigsci_site_rule.GBbCcDdEeFfGAHBICJDaEbFe
+}
+import {
+  id = "www:61b2c3d4e5f60718293a4b60"
+  to = sigsci_site_rule.GBbCcDdEeFfGAHBICJDaEbGA
+}
+import {
+  id = "www:LOGINATTEMPT"
+  to = sigsci_site_templated_rule.wwwLOGINATTEMPT
+}
+import {
+  id = "www:site.login-attempt"
+  to = sigsci_site_signal_tag.wwwsitedotlogin-attempt
+}
+import {
+  id = "www:site.office-ips"
+  to = sigsci_site_list.wwwsitedotoffice-ips
+}
+import {
+  id = "www:62c3d4e5f60718293a4b5c6d"
+  to = sigsci_site_integration.GCcDdEeFfGAHBICJDaEbFcGd
+}
+import {
+  id = "www:63d4e5f60718293a4b5c6d7e"
+  to = sigsci_site_header_link.wwwGDdEeFfGAHBICJDaEbFcGdHe
+}
+import {
+  id = "www:64e5f60718293a4b5c6d7e90"
+  to = sigsci_site_agent_alert.GEeFfGAHBICJDaEbFcGdHeJA
+}
+import {
+  id = "www:64e5f60718293a4b5c6d7e8f"
+  to = sigsci_site_alert.GEeFfGAHBICJDaEbFcGdHeIf
+}
+import {
+  id = "www:64e5f60718293a4b5c6d7e91"
+  to = sigsci_site_alert.GEeFfGAHBICJDaEbFcGdHeJB
+}
+import {
+  id = "api2:66a718293a4b5c6d7e8f9012"
+  to = sigsci_site_rule.GGaHBICJDaEbFcGdHeIfJABC
+}
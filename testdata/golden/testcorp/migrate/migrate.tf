@@ -0,0 +1,71 @@
+resource "sigsci_site_alert" "GFfGAHBICJDaEbFcGdHeIfJB" {
+  site_short_name    = "www"
+  tag_name           = "LOGINATTEMPT"
+  long_name          = "LOGINATTEMPT alert"
+  interval           = 1
+  threshold          = 10
+  enabled            = true
+  action             = "info"
+  skip_notifications = false
+}
+
+resource "sigsci_site_rule" "GHbICJDaEbFcGdHeIfJABCDE" {
+  site_short_name = "www"
+  type            = "templatedSignal"
+  group_operator  = "all"
+  enabled         = true
+  reason          = "Migrated from templated rule PW-RESET-ATTEMPT"
+  signal          = "PW-RESET-ATTEMPT"
+  conditions {
+    type     = "single"
+    field    = "path"
+    operator = "like"
+    value    = "/password/*"
+  }
+  actions {
+    type   = "addSignal"
+    signal = "PW-RESET-ATTEMPT"
+  }
+}
+
+resource "sigsci_site_rule" "GHbICJDaEbFcGdHeIfJABCDF" {
+  site_short_name = "www"
+  type            = "templatedSignal"
+  group_operator  = "all"
+  enabled         = false
+  reason          = "Migrated from templated rule PW-RESET-ATTEMPT"
+  signal          = "PW-RESET-ATTEMPT"
+  conditions {
+    type     = "single"
+    field    = "path"
+    operator = "equals"
+    value    = "/reset"
+  }
+  actions {
+    type   = "addSignal"
+    signal = "PW-RESET-ATTEMPT"
+  }
+}
+
+resource "sigsci_site_alert" "GHbICJDaEbFcGdHeIfJABCDH" {
+  site_short_name    = "www"
+  tag_name           = "PW-RESET-ATTEMPT"
+  long_name          = "Password resets"
+  interval           = 10
+  threshold          = 20
+  enabled            = true
+  action             = "info"
+  skip_notifications = false
+}
+
+resource "sigsci_site_alert" "GHbICJDaEbFcGdHeIfJABCDI" {
+  site_short_name    = "www"
+  tag_name           = "PW-RESET-ATTEMPT"
+  long_name          = "Password reset flood"
+  interval           = 1
+  threshold          = 100
+  enabled            = true
+  action             = "flagged"
+  skip_notifications = false
+}
+
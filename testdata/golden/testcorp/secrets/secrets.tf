@@ -0,0 +1,5 @@
+variable "site_integration_GCcDdEeFfGAHBICJDaEbFcGd_url" {
+  type        = string
+  description = "url of sigsci_site_integration.GCcDdEeFfGAHBICJDaEbFcGd (www:62c3d4e5f60718293a4b5c6d)"
+  sensitive   = true
+}
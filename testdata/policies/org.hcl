@@ -0,0 +1,23 @@
+policy "sites_block" {
+  description = "Every site must be in blocking mode"
+  resource    = "sigsci_site"
+  condition   = self.agent_level == "block"
+  message     = "site ${self.short_name} is in ${self.agent_level} mode"
+}
+
+policy "block_rules_expire" {
+  description = "Block rules need an expiration or a ticket in their reason"
+  resource    = "sigsci_site_rule"
+  where       = contains([for action in try(self.actions, []) : action.type], "block")
+  condition   = try(self.expiration, "") != "" || can(regex("[A-Z]+-[0-9]+", self.reason))
+}
+
+policy "no_sanctioned_countries_allowed" {
+  description = "No rule may allow a country list holding sanctioned countries"
+  resource    = "sigsci_site_rule"
+  where       = contains([for action in try(self.actions, []) : action.type], "allow")
+  condition = length(setintersection(["CU", "IR", "KP", "SY"], flatten([
+    for condition in try(self.conditions, []) : lists[condition.value].entries
+    if try(condition.field, "") == "country" && try(condition.operator, "") == "inList"
+  ]))) == 0
+}
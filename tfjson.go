@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Output formats for the generated configuration
+const (
+	formatHCL  = "hcl"
+	formatJSON = "json"
+)
+
+func validFormat(format string) error {
+	if format != formatHCL && format != formatJSON {
+		return fmt.Errorf("unknown format %q, expected %s or %s", format, formatHCL, formatJSON)
+	}
+	return nil
+}
+
+// convert_terraform_files_to_json replaces each of the named .tf files in
+// dir with its Terraform JSON equivalent (<name>.json). Missing files are
+// skipped.
+func convert_terraform_files_to_json(dir string, names ...string) error {
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		src, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("error reading %s: %v", path, err)
+		}
+
+		out, err := hcl_to_terraform_json(src, path)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(path+".json", out, 0666); err != nil {
+			return fmt.Errorf("error writing %s.json: %v", path, err)
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hcl_to_terraform_json renders Terraform native syntax as Terraform JSON
+// syntax with the same meaning. Keys are sorted, so the output is stable.
+func hcl_to_terraform_json(src []byte, filename string) ([]byte, error) {
+	file, diags := hclsyntax.ParseConfig(src, filename, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("error parsing %s: %v", filename, diags)
+	}
+
+	root, err := body_to_json(file.Body.(*hclsyntax.Body), src, "")
+	if err != nil {
+		return nil, fmt.Errorf("error converting %s: %v", filename, err)
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(root); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// body_to_json converts a body. blockType is the type of the top-level block
+// the body belongs to, empty for the file itself.
+func body_to_json(body *hclsyntax.Body, src []byte, blockType string) (map[string]interface{}, error) {
+	object := map[string]interface{}{}
+
+	for name, attr := range body.Attributes {
+		if isBareAttribute(blockType, name) {
+			object[name] = bare_expression_to_json(attr.Expr, src)
+			continue
+		}
+		value, err := expression_to_json(attr.Expr, src)
+		if err != nil {
+			return nil, err
+		}
+		object[name] = value
+	}
+
+	for _, block := range body.Blocks {
+		childType := blockType
+		if childType == "" {
+			childType = block.Type
+		}
+		content, err := body_to_json(block.Body, src, childType)
+		if err != nil {
+			return nil, err
+		}
+
+		// Labels become nested object keys, repeated blocks become arrays
+		parent := object
+		key := block.Type
+		for _, label := range block.Labels {
+			child, ok := parent[key].(map[string]interface{})
+			if !ok {
+				child = map[string]interface{}{}
+				parent[key] = child
+			}
+			parent, key = child, label
+		}
+		switch existing := parent[key].(type) {
+		case nil:
+			parent[key] = content
+		case []interface{}:
+			parent[key] = append(existing, content)
+		default:
+			parent[key] = []interface{}{existing, content}
+		}
+	}
+
+	return object, nil
+}
+
+// isBareAttribute reports whether Terraform's JSON syntax expects the
+// attribute as a naked reference rather than a template
+func isBareAttribute(blockType string, name string) bool {
+	switch blockType {
+	case "import", "moved", "removed":
+		return name == "to" || name == "from" || name == "provider"
+	case "resource", "data":
+		// iterator belongs to dynamic blocks
+		return name == "provider" || name == "depends_on" || name == "iterator"
+	case "variable":
+		return name == "type"
+	}
+	return false
+}
+
+func bare_expression_to_json(expr hclsyntax.Expression, src []byte) interface{} {
+	if tuple, ok := expr.(*hclsyntax.TupleConsExpr); ok {
+		var items []interface{}
+		for _, item := range tuple.Exprs {
+			items = append(items, bare_expression_to_json(item, src))
+		}
+		return items
+	}
+	return string(expr.Range().SliceBytes(src))
+}
+
+func expression_to_json(expr hclsyntax.Expression, src []byte) (interface{}, error) {
+	if len(expr.Variables()) == 0 {
+		if value, diags := expr.Value(nil); !diags.HasErrors() {
+			return cty_to_json(value)
+		}
+	}
+	// References and function calls stay expressions
+	return "${" + strings.TrimSpace(string(expr.Range().SliceBytes(src))) + "}", nil
+}
+
+func cty_to_json(value cty.Value) (interface{}, error) {
+	if value.IsNull() {
+		return nil, nil
+	}
+	if !value.IsKnown() {
+		return nil, fmt.Errorf("unknown value")
+	}
+
+	ty := value.Type()
+	switch {
+	case ty == cty.String:
+		// JSON strings are templates, so literal interpolation markers
+		// must be escaped
+		s := strings.ReplaceAll(value.AsString(), "${", "$${")
+		return strings.ReplaceAll(s, "%{", "%%{"), nil
+	case ty == cty.Number:
+		return json.Number(value.AsBigFloat().Text('f', -1)), nil
+	case ty == cty.Bool:
+		return value.True(), nil
+	case ty.IsListType(), ty.IsSetType(), ty.IsTupleType():
+		items := []interface{}{}
+		for it := value.ElementIterator(); it.Next(); {
+			_, element := it.Element()
+			item, err := cty_to_json(element)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	case ty.IsMapType(), ty.IsObjectType():
+		object := map[string]interface{}{}
+		for it := value.ElementIterator(); it.Next(); {
+			key, element := it.Element()
+			item, err := cty_to_json(element)
+			if err != nil {
+				return nil, err
+			}
+			object[key.AsString()] = item
+		}
+		return object, nil
+	}
+	return nil, fmt.Errorf("unsupported value of type %s", ty.FriendlyName())
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	hcljson "github.com/hashicorp/hcl/v2/json"
+)
+
+func TestConvertImportsToJSONGolden(t *testing.T) {
+	_, sc, api := newFakeAPI(t, "testcorp")
+	outputDir := t.TempDir()
+
+	if err := terraformify_corp(context.Background(), sc, api, "testcorp", outputDir, terraformOptions, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := convert_terraform_files_to_json(outputDir, "import.tf"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(filepath.Join(outputDir, "import.tf")); !os.IsNotExist(err) {
+		t.Error("import.tf should be replaced by import.tf.json")
+	}
+	assertGolden(t, filepath.Join(outputDir, "import.tf.json"), filepath.Join("testcorp", "import.tf.json"))
+}
+
+func TestConvertResourcesToJSONGolden(t *testing.T) {
+	src, err := os.ReadFile(filepath.Join("testdata", "golden", "promote", "promote.tf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := hcl_to_terraform_json(src, "promote.tf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, diags := hcljson.Parse(out, "promote.tf.json"); diags.HasErrors() {
+		t.Fatal(diags)
+	}
+
+	path := filepath.Join(t.TempDir(), "promote.tf.json")
+	if err := os.WriteFile(path, out, 0644); err != nil {
+		t.Fatal(err)
+	}
+	assertGolden(t, path, filepath.Join("promote", "promote.tf.json"))
+}
+
+func TestHCLToTerraformJSONEscapesTemplates(t *testing.T) {
+	src := []byte(`
+variable "NGWAF_TOKEN" {
+  type      = string
+  sensitive = true
+}
+
+resource "sigsci_site_header_link" "trace" {
+  link       = "https://tracing.example.com/$${value}"
+  entries    = split("\n", trimspace(file("lists/a.txt")))
+  depends_on = [sigsci_site.www]
+}
+`)
+
+	out, err := hcl_to_terraform_json(src, "test.tf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{
+  "resource": {
+    "sigsci_site_header_link": {
+      "trace": {
+        "depends_on": [
+          "sigsci_site.www"
+        ],
+        "entries": "${split(\"\\n\", trimspace(file(\"lists/a.txt\")))}",
+        "link": "https://tracing.example.com/$${value}"
+      }
+    }
+  },
+  "variable": {
+    "NGWAF_TOKEN": {
+      "sensitive": true,
+      "type": "string"
+    }
+  }
+}
+`
+	if string(out) != want {
+		t.Errorf("got\n%s\nwant\n%s", out, want)
+	}
+}
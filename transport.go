@@ -0,0 +1,84 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	sigsci "github.com/signalsciences/go-sigsci"
+)
+
+// TransportConfig controls where API calls go and how they get there
+type TransportConfig struct {
+	// APIURL is the API base URL, e.g. http://127.0.0.1:8080/api for a local mock
+	APIURL string
+	// ProxyURL overrides HTTPS_PROXY/HTTP_PROXY when set
+	ProxyURL string
+	// CABundle is a PEM file trusted in addition to the system roots
+	CABundle string
+}
+
+// LoadTransportConfig reads the transport settings from the environment
+func LoadTransportConfig() TransportConfig {
+	cfg := TransportConfig{
+		APIURL:   os.Getenv("NGWAF_API_URL"),
+		ProxyURL: os.Getenv("NGWAF_PROXY"),
+		CABundle: os.Getenv("NGWAF_CA_BUNDLE"),
+	}
+	if cfg.APIURL == "" {
+		cfg.APIURL = defaultAPIURL
+	}
+	cfg.APIURL = strings.TrimSuffix(cfg.APIURL, "/")
+	return cfg
+}
+
+// NewHTTPTransport builds a transport honouring the proxy and CA settings
+func (cfg TransportConfig) NewHTTPTransport() (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if cfg.ProxyURL != "" {
+		proxyURL, err := url.Parse(cfg.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL %q: %v", cfg.ProxyURL, err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if cfg.CABundle != "" {
+		pem, err := os.ReadFile(cfg.CABundle)
+		if err != nil {
+			return nil, fmt.Errorf("error reading CA bundle: %v", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", cfg.CABundle)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+
+	return transport, nil
+}
+
+// Apply points both the go-sigsci client and the given APIClient at the
+// configured API. go-sigsci builds a bare http.Client per request, so it is
+// steered through http.DefaultTransport and sigsci.SetAPIUrl.
+func (cfg TransportConfig) Apply(api *APIClient) error {
+	transport, err := cfg.NewHTTPTransport()
+	if err != nil {
+		return err
+	}
+
+	http.DefaultTransport = transport
+	sigsci.SetAPIUrl(cfg.APIURL)
+
+	api.BaseURL = cfg.APIURL
+	api.HTTPClient = &http.Client{Transport: transport}
+	return nil
+}
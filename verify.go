@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	sigsci "github.com/signalsciences/go-sigsci"
+)
+
+// expected_body converts the API object of target to the body type its
+// resource decodes to. Sites and templated rules have none.
+func expected_body(target ImportTarget) (interface{}, bool) {
+	switch object := target.Object.(type) {
+	case sigsci.ResponseCorpRuleBody:
+		return object.CreateCorpRuleBody, true
+	case sigsci.ResponseSiteRuleBody:
+		return object.CreateSiteRuleBody, true
+	case sigsci.ResponseListBody:
+		return object.CreateListBody, true
+	case sigsci.ResponseSignalTagBody:
+		return object.CreateSignalTagBody, true
+	case sigsci.CustomAlert:
+		return alertBody(object), true
+	case sigsci.Integration:
+		return sigsci.IntegrationBody{URL: object.URL, Type: object.Type, Events: object.Events}, true
+	case sigsci.HeaderLink:
+		return sigsci.HeaderLinkBody{Type: object.Type, Name: object.Name, LinkName: object.LinkName, Link: object.Link}, true
+	}
+	return nil, false
+}
+
+// normalize_body drops differences the API ignores: list entries are a set
+func normalize_body(body interface{}) interface{} {
+	if list, ok := body.(sigsci.CreateListBody); ok {
+		list.Entries = uniqueSortedEntries(list.Entries)
+		return list
+	}
+	return body
+}
+
+// known_ids are the API IDs of the list and signal targets, which
+// references to them resolve to
+func known_ids(imports *ImportSet) map[string]string {
+	ids := map[string]string{}
+	for _, target := range imports.Targets {
+		switch object := target.Object.(type) {
+		case sigsci.ResponseListBody:
+			ids[target.Address()] = object.ID
+		case sigsci.ResponseSignalTagBody:
+			ids[target.Address()] = object.TagName
+		}
+	}
+	return ids
+}
+
+// diff_values appends a description of every difference between want, from
+// the API, and got, from the configuration, to diffs. Fields are named
+// after their JSON keys.
+func diff_values(path string, want reflect.Value, got reflect.Value, diffs *[]string) {
+	switch want.Kind() {
+	case reflect.Struct:
+		for i := 0; i < want.NumField(); i++ {
+			field := want.Type().Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "" {
+				name = field.Name
+			}
+			if path != "" {
+				name = path + "." + name
+			}
+			diff_values(name, want.Field(i), got.Field(i), diffs)
+		}
+	case reflect.Ptr:
+		switch {
+		case want.IsNil() && got.IsNil():
+		case want.IsNil():
+			*diffs = append(*diffs, fmt.Sprintf("%s is not set in the API, but rendered", path))
+		case got.IsNil():
+			*diffs = append(*diffs, fmt.Sprintf("%s is set in the API, but lost in rendering", path))
+		default:
+			diff_values(path, want.Elem(), got.Elem(), diffs)
+		}
+	case reflect.Slice:
+		if want.Len() != got.Len() {
+			*diffs = append(*diffs, fmt.Sprintf("%s has %d items in the API, %d rendered", path, want.Len(), got.Len()))
+			return
+		}
+		for i := 0; i < want.Len(); i++ {
+			diff_values(fmt.Sprintf("%s[%d]", path, i), want.Index(i), got.Index(i), diffs)
+		}
+	default:
+		if want.Interface() != got.Interface() {
+			*diffs = append(*diffs, fmt.Sprintf("%s is %#v in the API, %#v rendered", path, want.Interface(), got.Interface()))
+		}
+	}
+}
+
+// verify_resources compares the decoded resources with the API objects of
+// the targets they belong to and describes every difference, every
+// resource without an object and every object without a resource
+func verify_resources(imports *ImportSet, resources []apiResource) []string {
+	targets := map[string]ImportTarget{}
+	for _, target := range imports.Targets {
+		targets[target.Address()] = target
+	}
+
+	var problems []string
+	seen := map[string]bool{}
+	for _, resource := range resources {
+		address := resource.Type + "." + resource.Name
+		if resource.Err != nil {
+			seen[address] = true
+			problems = append(problems, resource.Err.Error())
+			continue
+		}
+		target, ok := targets[address]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s: no such object in the API", resource.Address))
+			continue
+		}
+		seen[address] = true
+
+		var diffs []string
+		if resource.Site != target.Site {
+			diffs = append(diffs, fmt.Sprintf("site_short_name is %q in the API, %q rendered", target.Site, resource.Site))
+		}
+		want, _ := expected_body(target)
+		diff_values("", reflect.ValueOf(normalize_body(want)), reflect.ValueOf(normalize_body(resource.Body)), &diffs)
+		for _, diff := range diffs {
+			problems = append(problems, fmt.Sprintf("%s (%s): %s", resource.Address, target.ID, diff))
+		}
+	}
+
+	for _, target := range imports.Targets {
+		if _, ok := expected_body(target); ok && !seen[target.Address()] {
+			problems = append(problems, fmt.Sprintf("%s (%s): not in the configuration", target.Address(), target.ID))
+		}
+	}
+	return problems
+}
+
+// render_verify_config renders every target the way the tool writes
+// configuration, for verifying the rendering itself
+func render_verify_config(imports *ImportSet) (configFile, error) {
+	file := hclwrite.NewEmptyFile()
+	for _, target := range imports.Targets {
+		config, err := target_config(target)
+		if err != nil {
+			return configFile{}, err
+		}
+		render_resource(file, target.ResourceType, target.Name, config)
+	}
+	return configFile{Path: "rendered.tf", Src: hclwrite.Format(file.Bytes())}, nil
+}
+
+// verify_corp compares the configuration files at paths, or without paths
+// the configuration the tool renders, with the objects of corp. It returns
+// the problems found and the number of resources compared.
+func verify_corp(ctx context.Context, sc sigsci.Client, api *APIClient, corp string, paths []string) ([]string, int, error) {
+	snapshot, err := fetch_corp_snapshot(ctx, sc, api, corp)
+	if err != nil {
+		return nil, 0, err
+	}
+	imports := import_set_from_snapshot(snapshot, nil)
+
+	var files []configFile
+	moduleDir := "."
+	if len(paths) == 0 {
+		rendered, err := render_verify_config(imports)
+		if err != nil {
+			return nil, 0, err
+		}
+		files = []configFile{rendered}
+	} else {
+		if files, err = read_config_files(paths); err != nil {
+			return nil, 0, err
+		}
+		moduleDir = filepath.Dir(paths[0])
+	}
+
+	decoded, err := decode_resources(files, moduleDir, known_ids(imports))
+	if err != nil {
+		return nil, 0, err
+	}
+	var resources []apiResource
+	for _, resource := range decoded {
+		// Sites and templated rules have no body to compare
+		if resource.Type != "sigsci_site" && resource.Type != "sigsci_site_templated_rule" {
+			resources = append(resources, resource)
+		}
+	}
+	return verify_resources(imports, resources), len(resources), nil
+}
+
+func run_verify(ctx context.Context, sc sigsci.Client, api *APIClient, args []string) error {
+	flags := flag.NewFlagSet("verify", flag.ExitOnError)
+	corp := flags.String("corp", os.Getenv("TF_VAR_NGWAF_CORP"), "corp to compare the configuration with")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: verify [-corp corp] [file.tf ...]")
+		fmt.Fprintln(flags.Output(), "Without files, verifies the configuration the tool renders for the corp.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	problems, count, err := verify_corp(ctx, sc, api, *corp, flags.Args())
+	if err != nil {
+		return err
+	}
+	for _, problem := range problems {
+		fmt.Println(problem)
+	}
+	fmt.Printf("verified %d resources against corp %s, sites and templated rules are not compared\n", count, *corp)
+	if len(problems) > 0 {
+		return fmt.Errorf("verify found %d problems", len(problems))
+	}
+	return nil
+}
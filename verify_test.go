@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestVerifyRenderedConfig(t *testing.T) {
+	_, sc, api := newFakeAPI(t, "testcorp")
+
+	problems, count, err := verify_corp(context.Background(), sc, api, "testcorp", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) > 0 {
+		t.Errorf("rendering lost or altered attributes:\n%v", problems)
+	}
+	if count == 0 {
+		t.Error("no resources compared")
+	}
+}
+
+func TestVerifyCompactOutput(t *testing.T) {
+	_, sc, api := newFakeAPI(t, "testcorp")
+	outputDir := t.TempDir()
+
+	opts := RunOptions{Format: formatHCL, Output: outputTerraform, Compact: true, ListFiles: listFilesTxt}
+	if err := terraformify_corp(context.Background(), sc, api, "testcorp", outputDir, opts, ""); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(outputDir, "import.tf")
+
+	problems, _, err := verify_corp(context.Background(), sc, api, "testcorp", []string{path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := fmt.Sprintf("error decoding sigsci_site_integration.this[%q]", sanitizeTfId("62c3d4e5f60718293a4b5c6d"))
+	if len(problems) != 1 || !strings.HasPrefix(problems[0], want) {
+		t.Errorf("expected only the unset secret to be reported, got %v", problems)
+	}
+
+	t.Setenv("TF_VAR_site_integration_"+sanitizeTfId("62c3d4e5f60718293a4b5c6d")+"_url", "https://hooks.slack.com/services/T000/B000/XXXXSECRET")
+	problems, _, err = verify_corp(context.Background(), sc, api, "testcorp", []string{path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) > 0 {
+		t.Errorf("compact output lost or altered attributes:\n%v", problems)
+	}
+}
+
+func TestVerifyReportsDifferences(t *testing.T) {
+	_, sc, api := newFakeAPI(t, "testcorp")
+
+	signal := "www" + sanitizeTfId("site.login-attempt")
+	alert := sanitizeTfId("64e5f60718293a4b5c6d7e8f")
+	rule := sanitizeTfId("61b2c3d4e5f60718293a4b60")
+	config := fmt.Sprintf(`
+resource "sigsci_site_signal_tag" %[1]q {
+  site_short_name = "www"
+  name            = "login-attempt"
+  description     = "Login attempts"
+}
+
+resource "sigsci_site_alert" %[2]q {
+  site_short_name    = "www"
+  tag_name           = sigsci_site_signal_tag.%[1]s.id
+  long_name          = "Too many logins"
+  interval           = 10
+  threshold          = 100
+  enabled            = true
+  action             = "info"
+  skip_notifications = false
+}
+
+resource "sigsci_site_rule" %[3]q {
+  site_short_name = "www"
+  type            = "request"
+  group_operator  = "all"
+  enabled         = true
+  reason          = "Tag traffic from blocked IPs"
+  conditions {
+    type     = "single"
+    field    = "ip"
+    operator = "inList"
+    value    = "corp.blocked-ips"
+  }
+}
+
+resource "sigsci_site_list" "extra" {
+  site_short_name = "www"
+  name            = "Extra"
+  type            = "ip"
+  description     = ""
+  entries         = []
+}
+`, signal, alert, rule)
+	path := filepath.Join(t.TempDir(), "main.tf")
+	if err := os.WriteFile(path, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	problems, count, err := verify_corp(context.Background(), sc, api, "testcorp", []string{path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 4 {
+		t.Errorf("compared %d resources, want 4", count)
+	}
+	for _, want := range []string{
+		fmt.Sprintf("sigsci_site_alert.%s (www:64e5f60718293a4b5c6d7e8f): threshold is 50 in the API, 100 rendered", alert),
+		fmt.Sprintf("sigsci_site_rule.%s (www:61b2c3d4e5f60718293a4b60): actions has 1 items in the API, 0 rendered", rule),
+		"sigsci_site_list.extra: no such object in the API",
+		"sigsci_site_list.www" + sanitizeTfId("site.office-ips") + " (www:site.office-ips): not in the configuration",
+	} {
+		if !slices.Contains(problems, want) {
+			t.Errorf("missing problem %q in:\n%v", want, problems)
+		}
+	}
+	// The signal matches, and the alert's reference to it resolves to its ID
+	for _, problem := range problems {
+		if strings.HasPrefix(problem, "sigsci_site_signal_tag."+signal) || strings.Contains(problem, "tagName") {
+			t.Errorf("unexpected problem %q", problem)
+		}
+	}
+}